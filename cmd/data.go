@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bookApp/internal/auth"
+	"bookApp/internal/domain/repos"
+	"bookApp/pkg/export"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+
+	"gorm.io/gorm"
+)
+
+// importCommand: imports the books and authors of a csv or ONIX file
+func importCommand(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	profileName := fs.String("profile", "", "name of the saved csv profile of the supplier, the default layout is used if empty")
+	profilesDir := fs.String("profiles", cfg.Import.ProfilesDir, "directory of the saved csv profiles")
+	update := fs.Bool("update", false, "overwrite the existing books with the rows of the file including their deletion, they are skipped otherwise")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: import [-profile name] [-profiles dir] [-update] <file>")
+	}
+
+	profile := repos.DefaultCSVProfile()
+	if *profileName != "" {
+		p, err := repos.LoadCSVProfile(*profilesDir, *profileName)
+		if err != nil {
+			return err
+		}
+		profile = p
+	}
+	return importFile(fs.Arg(0), profile, *update)
+}
+
+// seedCommand: loads the sample data
+func seedCommand(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := fs.String("file", cfg.Import.SeedFile, "file of the sample data")
+	fs.Parse(args)
+	if *file == "" {
+		return fmt.Errorf("no seed file is configured")
+	}
+
+	return importFile(*file, repos.DefaultCSVProfile(), false)
+}
+
+// importFile: imports the books of the file together with their authors, the existing books are overwritten if update is set
+func importFile(path string, profile repos.CSVProfile, update bool) error {
+	db, closeDb, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeDb()
+
+	if err := requireSchema(db); err != nil {
+		return err
+	}
+	if update {
+		return repos.NewBookRepository(db).UpdateBookData(operatorContext(), path, profile)
+	}
+	return repos.NewBookRepository(db).InsertBookData(operatorContext(), path, profile)
+}
+
+// operatorContext: the context of the changes made by the commands, the audit trail records the os user running them
+func operatorContext() context.Context {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return auth.NewContext(context.Background(), &auth.Principal{Subject: name, Method: auth.MethodCLI})
+}
+
+// exportCommand: writes the books or authors to a file or to the standard output
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	kind := fs.String("type", "books", "books or authors")
+	formatName := fs.String("format", "csv", "csv, ndjson, xlsx or onix (books only)")
+	includeDeleted := fs.Bool("deleted", false, "include soft deleted rows")
+	output := fs.String("o", "", "output file, the standard output is used if empty")
+	fs.Parse(args)
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	if *kind != "books" && *kind != "authors" {
+		return fmt.Errorf("export type must be books or authors: %s", *kind)
+	}
+
+	db, closeDb, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeDb()
+	if err := requireSchema(db); err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return writeExport(db, os.Stdout, *kind, format, *includeDeleted)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeExport(db, f, *kind, format, *includeDeleted); err != nil {
+		f.Close()
+		return err
+	}
+	// a failed close may lose the written data (e.g. on a network file system), the export fails then
+	return f.Close()
+}
+
+// writeExport: writes the books or authors to w in the given format
+func writeExport(db *gorm.DB, w io.Writer, kind string, format export.Format, includeDeleted bool) error {
+	if kind == "authors" {
+		return repos.NewAuthorRepository(db).WriteAuthors(context.Background(), w, format, includeDeleted)
+	}
+	return repos.NewBookRepository(db).WriteBooks(context.Background(), w, format, includeDeleted)
+}
+
+// checkStockCommand: lists the books that are running out of stock
+func checkStockCommand(args []string) error {
+	fs := flag.NewFlagSet("check-stock", flag.ExitOnError)
+	below := fs.Int("below", 0, "books with at most this many books in stock are listed")
+	fs.Parse(args)
+
+	db, closeDb, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeDb()
+	if err := requireSchema(db); err != nil {
+		return err
+	}
+
+	books, err := repos.NewBookRepository(db).FindAllStockAtMost(context.Background(), *below)
+	if err != nil {
+		return err
+	}
+	if len(books) == 0 {
+		fmt.Printf("All books have more than %d book/s in stock.\n", *below)
+		return nil
+	}
+	for _, book := range books {
+		fmt.Print(book.ToString())
+	}
+	return nil
+}
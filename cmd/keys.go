@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bookApp/internal/api/router"
+	"bookApp/internal/auth"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// keysCommand: creates, lists and revokes the api keys of the services
+func keysCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: keys create [-role r1,r2] <name> | list | revoke <id>")
+	}
+	store, err := auth.OpenKeyStore(cfg.Auth.KeysFile)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("keys create", flag.ExitOnError)
+		roleNames := fs.String("role", string(auth.RoleReader), "comma separated roles of the key: "+strings.Join(allRoles(), ", "))
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: keys create [-role r1,r2] <name>")
+		}
+		roles, err := auth.ParseRoles(*roleNames)
+		if err != nil {
+			return err
+		}
+		k, key, err := store.Create(fs.Arg(0), roles)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created api key %s for %s with the roles %s, it is not shown again:\n%s\n", k.ID, k.Name, joinRoles(k.Roles), key)
+		return nil
+	case "list":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tROLES\tCREATED\tREVOKED")
+		for _, k := range store.List() {
+			revoked := "-"
+			if k.Revoked() {
+				revoked = k.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, joinRoles(k.Roles), k.CreatedAt.Format(time.RFC3339), revoked)
+		}
+		return tw.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: keys revoke <id>")
+		}
+		k, err := store.Revoke(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Revoked api key %s of %s\n", k.ID, k.Name)
+		return nil
+	}
+	return fmt.Errorf("unknown keys command %s, must be create, list or revoke", args[0])
+}
+
+// tokenCommand: issues a bearer token of a user signed with the configured jwt secret
+func tokenCommand(args []string) error {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	subject := fs.String("sub", "", "user the token is issued to")
+	roleNames := fs.String("role", string(auth.RoleReader), "comma separated roles of the user: "+strings.Join(allRoles(), ", "))
+	ttl := fs.Duration("ttl", cfg.Auth.TokenTTL, "lifetime of the token")
+	fs.Parse(args)
+
+	issuer, err := newTokenIssuer()
+	if err != nil {
+		return err
+	}
+	if issuer == nil {
+		return fmt.Errorf("no jwt secret is configured")
+	}
+	roles, err := auth.ParseRoles(*roleNames)
+	if err != nil {
+		return err
+	}
+	token, err := issuer.Issue(*subject, roles, *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
+
+// newAuthenticator: creates the authenticator of the configured api keys and jwt secret
+func newAuthenticator() (*auth.Authenticator, error) {
+	keys, err := auth.OpenKeyStore(cfg.Auth.KeysFile)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := newTokenIssuer()
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Authenticator{Keys: keys, Tokens: tokens}, nil
+}
+
+// newTokenIssuer: returns nil if there is no jwt secret, bearer tokens are not accepted then
+func newTokenIssuer() (*auth.TokenIssuer, error) {
+	if cfg.Auth.JWTSecret == "" {
+		return nil, nil
+	}
+	return auth.NewTokenIssuer(cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer)
+}
+
+// routesCommand: prints the permission each route requires and the roles having it
+func routesCommand(args []string) error {
+	fs := flag.NewFlagSet("routes", flag.ExitOnError)
+	fs.Parse(args)
+
+	mr := mux.NewRouter()
+	router.Handle(mr)
+	routes, err := router.RoutePermissions(mr)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHODS\tPATH\tQUERY\tPERMISSION\tROLES")
+	for _, r := range routes {
+		methods := strings.Join(r.Methods, ",")
+		if methods == "" && r.Name != "" {
+			// routes matching the method with a matcher func, e.g. the preflight requests
+			methods = r.Name
+		} else if methods == "" {
+			methods = "*"
+		}
+		roles := joinRoles(r.Roles)
+		if r.Permission == auth.PermPublic {
+			roles = "anyone"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", methods, r.Path, strings.Join(r.Queries, "&"), r.Permission, roles)
+	}
+	return tw.Flush()
+}
+
+// allRoles: the names of every role
+func allRoles() []string {
+	names := make([]string, len(auth.Roles))
+	for i, r := range auth.Roles {
+		names[i] = string(r)
+	}
+	return names
+}
+
+// joinRoles: the comma separated names of the roles, keys and users without roles are readers
+func joinRoles(roles []auth.Role) string {
+	if len(roles) == 0 {
+		return string(auth.RoleReader)
+	}
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = string(r)
+	}
+	return strings.Join(names, ",")
+}
@@ -1,88 +1,131 @@
 package main
 
 import (
-	"bookApp/internal/api/router"
+	"bookApp/internal/config"
+	"bookApp/internal/domain/migrations"
 	"bookApp/internal/domain/repos"
-	postgres "bookApp/pkg/db"
+	database "bookApp/pkg/db"
+	"bookApp/pkg/logger"
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"time"
+	"text/tabwriter"
 
-	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+// command: a subcommand of the app that is run with the arguments following its name
+type command struct {
+	name        string
+	args        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "[-addr host:port] [-seed file]", "start the http server (default command)", serveCommand},
+	{"import", "[-profile name] [-profiles dir] [-update] <file>", "import books and authors from a csv or ONIX file", importCommand},
+	{"export", "[-type books|authors] [-format csv|ndjson|xlsx|onix] [-deleted] [-o file]", "export the catalogue", exportCommand},
+	{"migrate", "up|down [steps]|status", "apply, roll back or list the schema migrations", migrateCommand},
+	{"seed", "[-file path]", "load the sample data", seedCommand},
+	{"check-stock", "[-below n]", "list the books with at most n books in stock", checkStockCommand},
+	{"keys", "create [-role r1,r2] <name>|list|revoke <id>", "manage the api keys of the services", keysCommand},
+	{"token", "-sub user [-role r1,r2] [-ttl duration]", "issue a bearer token of a user", tokenCommand},
+	{"routes", "", "list the routes with the permission they require and the roles having it", routesCommand},
+	{"openapi", "[-o file]", "write the openapi document, fails if a route is not documented", openapiCommand},
+}
+
+var (
+	cfg         *config.Config
+	globalFlags = flag.NewFlagSet("bookApp", flag.ExitOnError)
 )
 
 func main() {
-	// Set environment variables
+	// Set environment variables from the .env file if there is one
 	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file.")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
 	}
 
-	// Initialize database
-	db, err := postgres.NewPsqlDB()
+	// Load configuration: defaults < config file < environment variables < flags
+	globalFlags.Usage = usage
+	var args []string
+	cfg, args, err = config.Load(globalFlags, os.Args[1:])
 	if err != nil {
-		log.Fatal("Postgres cannot be initalized.")
+		log.Fatal(err)
 	}
-
-	// Close db connection
-	sqlDb, err := db.DB()
+	l, err := cfg.Logger()
 	if err != nil {
-		log.Fatal("Database connection cannot be closed.")
+		log.Fatal(err)
 	}
-	defer sqlDb.Close()
-
-	log.Println("Postgress connected")
-
-	// Repositories
-	router.BookRepo = repos.NewBookRepository(db)
-	router.AuthorRepo = repos.NewAuthorRepository(db)
+	logger.SetDefault(l)
+	repos.CSVWorkers = cfg.Import.Workers
+	repos.CSVQueueSize = cfg.Import.QueueSize
 
-	// Setup databases
-	router.BookRepo.SetupDatabase("./pkg/docs/data.csv")
-	router.AuthorRepo.SetupDatabase("./pkg/docs/data.csv")
-
-	// Create mux router
-	r := mux.NewRouter()
-	router.Handle(r)
-
-	// Initialize server
-	srv := &http.Server{
-		Addr:         "127.0.0.1:8090",
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
-		Handler:      r,
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			log.Println(err)
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				logger.Error("command failed", "command", c.name, "error", err)
+				os.Exit(1)
+			}
+			return
 		}
-	}()
-
-	GracefulShutdown(srv, 15*time.Second)
-
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %s\n\n", name)
+	usage()
+	os.Exit(2)
 }
 
-func GracefulShutdown(srv *http.Server, timeout time.Duration) {
-	c := make(chan os.Signal, 1)
-
-	// when there is a interrupt signal, relay it to the channel
-	signal.Notify(c, os.Interrupt)
+// usage: prints the commands of the app
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [global flags] <command> [flags]\n\nCommands:\n", os.Args[0])
+	tw := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", c.name, c.args, c.description)
+	}
+	tw.Flush()
+	fmt.Fprintf(os.Stderr, "\nGlobal flags:\n")
+	globalFlags.PrintDefaults()
+}
 
-	// block until any signal is received by the channel
-	<-c
+// connect: opens the database connection, the returned function closes it
+func connect() (*gorm.DB, func(), error) {
+	// Initialize database
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Database (%s) cannot be initalized: %v", cfg.Database.Driver, err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Database connection cannot be closed: %v", err)
+	}
 
-	// wait until the timeout deadline and shutdown the server if there is no connections. if there is no connection shutdown immediately
-	srv.Shutdown(ctx)
+	logger.Info("database connected", "driver", cfg.Database.Driver)
+	return db, func() { sqlDb.Close() }, nil
+}
 
-	log.Println("shutting down the server")
-	os.Exit(0)
+// requireSchema: returns an error if there are migrations that are not applied to the database
+func requireSchema(db *gorm.DB) error {
+	pending, err := migrations.NewMigrator(db).CountPending(context.Background())
+	if err != nil {
+		return fmt.Errorf("Migration status cannot be read: %v", err)
+	}
+	if pending > 0 {
+		return fmt.Errorf("Database schema is behind by %d migration/s, run `migrate up` first.", pending)
+	}
+	return nil
 }
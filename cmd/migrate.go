@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bookApp/internal/domain/migrations"
+	"bookApp/pkg/logger"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// migrateCommand: applies, rolls back or lists the schema migrations with the given arguments
+func migrateCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [steps]|status")
+	}
+
+	db, closeDb, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeDb()
+
+	migrator := migrations.NewMigrator(db)
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up()
+		for _, m := range done {
+			logger.Info("migration applied", "version", m.Version, "name", m.Name)
+		}
+		if err == nil && len(done) == 0 {
+			logger.Info("schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("steps must be a positive number: %s", args[1])
+			}
+			steps = n
+		}
+		done, err := migrator.Down(steps)
+		for _, m := range done {
+			logger.Info("migration rolled back", "version", m.Version, "name", m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied at " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%d_%s: %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %s, usage: migrate up|down [steps]|status", args[0])
+}
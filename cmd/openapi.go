@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bookApp/internal/api/router"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/gorilla/mux"
+)
+
+// openapiCommand: writes the OpenAPI document of the routes to a file or to the standard output
+// it fails if a registered route is not documented or a documented operation is not registered, so it can be run before a release
+func openapiCommand(args []string) error {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	output := fs.String("o", "", "output file, the standard output is used if empty")
+	fs.Parse(args)
+
+	mr := mux.NewRouter()
+	router.Handle(mr)
+	doc, err := router.OpenAPI(mr)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
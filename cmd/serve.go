@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bookApp/internal/api/router"
+	"bookApp/internal/cors"
+	"bookApp/internal/domain/migrations"
+	"bookApp/internal/domain/repos"
+	"bookApp/internal/metrics"
+	"bookApp/internal/ratelimit"
+	"bookApp/internal/tracing"
+	database "bookApp/pkg/db"
+	"bookApp/pkg/logger"
+	"bookApp/pkg/shutdown"
+	"bookApp/pkg/tlsreload"
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// serveCommand: seeds the database and serves the rest API until an interrupt or terminate signal is received
+func serveCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", cfg.Server.Addr, "address the http server listens on")
+	seed := fs.String("seed", cfg.Import.SeedFile, "file loaded into the database before serving, empty to skip")
+	fs.Parse(args)
+
+	db, closeDb, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeDb()
+
+	// An in-memory database is empty on every start, its schema is always created
+	if cfg.Database.Driver == database.DriverMemory {
+		if _, err := migrations.NewMigrator(db).Up(); err != nil {
+			return err
+		}
+	}
+
+	// Refuse to serve with an outdated schema
+	if err := requireSchema(db); err != nil {
+		return err
+	}
+
+	// Background workers are stopped and waited for on shutdown
+	coordinator := shutdown.NewCoordinator()
+
+	// Probe the database in the background until the server is shut down
+	monitor, err := database.NewMonitor(db, cfg.Database.Health, cfg.Database.Pool.MaxIdleConns)
+	if err != nil {
+		return err
+	}
+	coordinator.Go("database monitor", monitor.Start)
+
+	// Expose the pool stats and the statement durations of the database as metrics
+	if err := metrics.RegisterPool(monitor); err != nil {
+		return err
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return err
+	}
+
+	// Trace the requests down to the sql statements, the remaining spans are flushed after the server is shut down
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("remaining spans cannot be exported", "error", err)
+		}
+	}()
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return err
+	}
+
+	// Repositories
+	router.BookRepo = repos.NewBookRepository(db)
+	router.AuthorRepo = repos.NewAuthorRepository(db)
+	router.AuditRepo = repos.NewAuditRepository(db)
+	router.Ready = router.NewReadiness(monitor, migrations.NewMigrator(db), cfg.Database.Health)
+
+	// Callers are identified by api keys and bearer tokens
+	router.Auth, err = newAuthenticator()
+	if err != nil {
+		return err
+	}
+
+	// Setup databases in the background, the app is not ready until the initial import is finished
+	coordinator.Go("initial import", func(ctx context.Context) {
+		if *seed != "" {
+			if err := router.BookRepo.SetupDatabase(ctx, *seed, repos.DefaultCSVProfile()); err != nil {
+				logger.Error("initial import of books failed", "file", *seed, "error", err)
+			}
+			if err := router.AuthorRepo.SetupDatabase(ctx, *seed, repos.DefaultCSVProfile()); err != nil {
+				logger.Error("initial import of authors failed", "file", *seed, "error", err)
+			}
+		}
+		router.Ready.MarkImportDone()
+	})
+
+	// Every caller has a budget of requests per route group
+	router.Limiter = ratelimit.New(ratelimit.NewMemoryStore(), cfg.RateLimit)
+	router.TrustedProxies, err = ratelimit.ParseProxies(cfg.RateLimit.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	// Browsers of the allowed origins can call the api, every response has the security headers
+	router.CORS, err = cors.New(cfg.CORS)
+	if err != nil {
+		return err
+	}
+	router.HSTSMaxAge = cfg.Server.TLS.HSTSMaxAge
+
+	// The root routes are deprecated aliases of the v1 routes until their sunset
+	router.LegacySunset = cfg.API.Sunset()
+
+	// Create mux router, the queries of a request are cancelled after the configured deadlines
+	router.QueryTimeout = cfg.Server.QueryTimeout
+	router.SearchTimeout = cfg.Server.SearchTimeout
+	r := mux.NewRouter()
+	router.Handle(r)
+
+	// Initialize server
+	srv := &http.Server{
+		Addr:         *addr,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		Handler:      r,
+	}
+
+	// Serve over https if there is a certificate, a renewed certificate is picked up without a restart
+	listen := srv.ListenAndServe
+	if cfg.Server.TLS.Enabled() {
+		certs, err := tlsreload.New(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.GetCertificate}
+		listen = func() error { return srv.ListenAndServeTLS("", "") }
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := listen(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	return GracefulShutdown(srv, router.Ready, coordinator, serverErr, cfg.Server.DrainDelay, cfg.Server.ShutdownTimeout)
+}
+
+// GracefulShutdown: blocks until an interrupt or terminate signal is received or the server fails,
+// then fails the readiness, stops accepting requests, waits for the in-flight requests and the background workers.
+// An error is returned if they are not finished until the timeout so that the app exits with a non-zero status.
+func GracefulShutdown(srv *http.Server, ready *router.Readiness, coordinator *shutdown.Coordinator, serverErr <-chan error, drainDelay, timeout time.Duration) error {
+	c := make(chan os.Signal, 2)
+
+	// when there is a interrupt or terminate signal, relay it to the channel
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(c)
+
+	// block until any signal is received by the channel or the server cannot serve anymore
+	var serveFailure, failure error
+	select {
+	case sig := <-c:
+		logger.Info("shutting down", "signal", sig.String())
+		// fail the readiness first and give the load balancer time to stop sending new requests
+		ready.MarkShuttingDown()
+		logger.Info("draining traffic", "delay", drainDelay.String())
+		time.Sleep(drainDelay)
+	case serveFailure = <-serverErr:
+		ready.MarkShuttingDown()
+	}
+
+	// a second signal stops waiting for the requests and workers
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		select {
+		case sig := <-c:
+			logger.Warn("received again, not waiting anymore", "signal", sig.String())
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// stop accepting new connections and wait until the in-flight requests are finished or the timeout deadline
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("in-flight requests are not finished", "error", err)
+		failure = err
+	}
+	logger.Info("shutting down the server")
+
+	// stop the background workers and wait for them with the rest of the timeout
+	if err := coordinator.Shutdown(ctx); err != nil {
+		logger.Error("background workers are not finished", "error", err)
+		failure = err
+	}
+
+	if serveFailure != nil {
+		return fmt.Errorf("server failed: %v", serveFailure)
+	}
+	if failure != nil {
+		return fmt.Errorf("shutdown is not graceful: %v", failure)
+	}
+	return nil
+}
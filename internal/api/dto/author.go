@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"bookApp/internal/domain/entities"
+	"time"
+)
+
+// Author: an author as the resource routes return it
+type Author struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Books: the books of the author if they are loaded with the author
+	Books     []Book    `json:"books,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NewAuthor: maps a stored author to its response in the view of the caller
+func NewAuthor(a *entities.Author, v View) Author {
+	author := Author{
+		ID:        a.ID,
+		Name:      a.Name,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+	if a.Books != nil {
+		author.Books = NewBooks(a.Books, v)
+	}
+	return author
+}
+
+// NewAuthors: maps the stored authors to their responses, the list is empty rather than nil
+func NewAuthors(authors []entities.Author, v View) []Author {
+	result := make([]Author, len(authors))
+	for i := range authors {
+		result[i] = NewAuthor(&authors[i], v)
+	}
+	return result
+}
+
+// AuthorRequest: the author given with a new book
+type AuthorRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Entity: maps the request to the author to store
+func (r *AuthorRequest) Entity() entities.Author {
+	return entities.Author{ID: r.ID, Name: r.Name}
+}
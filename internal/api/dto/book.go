@@ -0,0 +1,112 @@
+package dto
+
+import (
+	"bookApp/internal/domain/entities"
+	"errors"
+	"time"
+)
+
+// Book: a book as the resource routes return it, the restricted fields are omitted for the callers not seeing them
+type Book struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	PageNumber  uint    `json:"pageNumber"`
+	StockNumber int     `json:"stockNumber"`
+	StockID     string  `json:"stockId,omitempty"`
+	Price       float32 `json:"price"`
+	ISBN        string  `json:"isbn"`
+	AuthorID    string  `json:"authorId"`
+	// Author: the author of the book if it is loaded with the book
+	Author    *Author    `json:"author,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// NewBook: maps a stored book to its response in the view of the caller
+func NewBook(b *entities.Book, v View) Book {
+	book := Book{
+		ID:          b.ID,
+		Name:        b.Name,
+		PageNumber:  b.PageNumber,
+		StockNumber: b.StockNumber,
+		Price:       b.Price,
+		ISBN:        b.ISBN,
+		AuthorID:    b.AuthorID,
+		CreatedAt:   b.CreatedAt,
+		UpdatedAt:   b.UpdatedAt,
+		Deleted:     b.DeletedAt.Valid,
+	}
+	if v.Shows(FieldStockID) {
+		book.StockID = b.StockID
+	}
+	if b.DeletedAt.Valid && v.Shows(FieldDeletedAt) {
+		deletedAt := b.DeletedAt.Time
+		book.DeletedAt = &deletedAt
+	}
+	if b.Author != nil {
+		author := NewAuthor(b.Author, v)
+		book.Author = &author
+	}
+	return book
+}
+
+// NewBooks: maps the stored books to their responses, the list is empty rather than nil
+func NewBooks(books []entities.Book, v View) []Book {
+	result := make([]Book, len(books))
+	for i := range books {
+		result[i] = NewBook(&books[i], v)
+	}
+	return result
+}
+
+// BookRequest: the body creating a book, the author is created with the book if it is given and new
+type BookRequest struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	PageNumber  uint           `json:"pageNumber"`
+	StockNumber int            `json:"stockNumber"`
+	StockID     string         `json:"stockId"`
+	Price       float32        `json:"price"`
+	ISBN        string         `json:"isbn"`
+	AuthorID    string         `json:"authorId"`
+	Author      *AuthorRequest `json:"author,omitempty"`
+}
+
+// Validate: checks the fields of the request that the storage does not
+func (r *BookRequest) Validate() error {
+	switch {
+	case r.ID == "" || r.Name == "":
+		return errors.New("book id and name is required")
+	case r.StockNumber < 0:
+		return errors.New("stockNumber cannot be negative")
+	case r.Price < 0:
+		return errors.New("price cannot be negative")
+	case r.Author != nil && (r.Author.ID == "" || r.Author.Name == ""):
+		return errors.New("author id and name is required")
+	case r.Author != nil && r.AuthorID != "" && r.AuthorID != r.Author.ID:
+		return errors.New("authorId must be the id of the author")
+	}
+	return nil
+}
+
+// Entity: maps the request to the book to store, the author ID is taken from the author if only the author is given
+func (r *BookRequest) Entity() entities.Book {
+	book := entities.Book{
+		ID:          r.ID,
+		Name:        r.Name,
+		PageNumber:  r.PageNumber,
+		StockNumber: r.StockNumber,
+		StockID:     r.StockID,
+		Price:       r.Price,
+		ISBN:        r.ISBN,
+		AuthorID:    r.AuthorID,
+	}
+	if r.Author != nil {
+		author := r.Author.Entity()
+		book.Author = &author
+		book.AuthorID = author.ID
+	}
+	return book
+}
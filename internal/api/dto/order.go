@@ -0,0 +1,30 @@
+package dto
+
+import "errors"
+
+// OrderRequest: the body of an order of a book
+type OrderRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// Order: the ordered book and quantity
+type Order struct {
+	BookID   string `json:"bookId"`
+	Quantity int    `json:"quantity"`
+}
+
+// StockRequest: the body of a stock adjustment, the counted stock of the book
+type StockRequest struct {
+	StockNumber *int `json:"stockNumber"`
+}
+
+// Validate: checks that the stock is given and not negative
+func (r *StockRequest) Validate() error {
+	switch {
+	case r.StockNumber == nil:
+		return errors.New("stockNumber is required")
+	case *r.StockNumber < 0:
+		return errors.New("stockNumber cannot be negative")
+	}
+	return nil
+}
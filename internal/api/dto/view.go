@@ -0,0 +1,40 @@
+package dto
+
+import "bookApp/internal/auth"
+
+// Field: a field of the responses that only some roles see
+type Field string
+
+const (
+	// FieldStockID: the warehouse stock ID of a book, the staff handling the orders and the stock see it
+	FieldStockID Field = "stockId"
+	// FieldDeletedAt: when a book was soft deleted, the staff managing the catalogue see it
+	FieldDeletedAt Field = "deletedAt"
+)
+
+// fieldRoles: the roles seeing the restricted fields, the other fields are shown to every caller
+var fieldRoles = map[Field][]auth.Role{
+	FieldStockID:   {auth.RoleClerk, auth.RoleInventoryManager, auth.RoleAdmin},
+	FieldDeletedAt: {auth.RoleInventoryManager, auth.RoleAdmin},
+}
+
+// View: the restricted fields shown to a caller
+type View map[Field]bool
+
+// ViewOf: the view of the principal of a request, the anonymous callers see no restricted field
+func ViewOf(p *auth.Principal) View {
+	v := View{}
+	for field, roles := range fieldRoles {
+		for _, role := range roles {
+			if p.HasRole(role) {
+				v[field] = true
+			}
+		}
+	}
+	return v
+}
+
+// Shows: reports whether the field is shown in the view
+func (v View) Shows(f Field) bool {
+	return v[f]
+}
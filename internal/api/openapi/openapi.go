@@ -0,0 +1,184 @@
+package openapi
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// spec: the hand maintained description of the routes and the shapes of their requests and responses
+//
+//go:embed openapi.yaml
+var spec []byte
+
+//go:embed ui
+var ui embed.FS
+
+// UI: the files of the docs page rendering the document served at /openapi.json
+func UI() fs.FS {
+	sub, err := fs.Sub(ui, "ui")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Document: the subset of an OpenAPI 3 document used to describe the api
+type Document struct {
+	OpenAPI    string              `yaml:"openapi" json:"openapi"`
+	Info       Info                `yaml:"info" json:"info"`
+	Tags       []Tag               `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Paths      map[string]PathItem `yaml:"paths" json:"paths"`
+	Components Components          `yaml:"components" json:"components"`
+}
+
+type Info struct {
+	Title       string `yaml:"title" json:"title"`
+	Version     string `yaml:"version" json:"version"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// PathItem: the operations of a path by their lower case method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `yaml:"operationId" json:"operationId"`
+	Summary     string                `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Description string                `yaml:"description,omitempty" json:"description,omitempty"`
+	Tags        []string              `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Parameters  []*Parameter          `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	RequestBody *RequestBody          `yaml:"requestBody,omitempty" json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `yaml:"responses" json:"responses"`
+	Security    []map[string][]string `yaml:"security,omitempty" json:"security,omitempty"`
+	Deprecated  bool                  `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
+	// Permission, RateLimitGroup: filled from the declarations of the route
+	Permission     string `yaml:"x-permission,omitempty" json:"x-permission,omitempty"`
+	RateLimitGroup string `yaml:"x-rate-limit-group,omitempty" json:"x-rate-limit-group,omitempty"`
+}
+
+type Parameter struct {
+	Ref         string  `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Name        string  `yaml:"name,omitempty" json:"name,omitempty"`
+	In          string  `yaml:"in,omitempty" json:"in,omitempty"`
+	Description string  `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool    `yaml:"required,omitempty" json:"required,omitempty"`
+	Schema      *Schema `yaml:"schema,omitempty" json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string                `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool                  `yaml:"required,omitempty" json:"required,omitempty"`
+	Content     map[string]*MediaType `yaml:"content" json:"content"`
+}
+
+type Response struct {
+	Ref         string                `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Description string                `yaml:"description,omitempty" json:"description,omitempty"`
+	Headers     map[string]*Header    `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Content     map[string]*MediaType `yaml:"content,omitempty" json:"content,omitempty"`
+}
+
+type Header struct {
+	Ref         string  `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Description string  `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool    `yaml:"required,omitempty" json:"required,omitempty"`
+	Schema      *Schema `yaml:"schema,omitempty" json:"schema,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema,omitempty" json:"schema,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Type                 string             `yaml:"type,omitempty" json:"type,omitempty"`
+	Format               string             `yaml:"format,omitempty" json:"format,omitempty"`
+	Description          string             `yaml:"description,omitempty" json:"description,omitempty"`
+	Nullable             bool               `yaml:"nullable,omitempty" json:"nullable,omitempty"`
+	Enum                 []string           `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Minimum              *float64           `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Properties           map[string]*Schema `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Required             []string           `yaml:"required,omitempty" json:"required,omitempty"`
+	AdditionalProperties *Schema            `yaml:"additionalProperties,omitempty" json:"additionalProperties,omitempty"`
+	Items                *Schema            `yaml:"items,omitempty" json:"items,omitempty"`
+	OneOf                []*Schema          `yaml:"oneOf,omitempty" json:"oneOf,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `yaml:"schemas,omitempty" json:"schemas,omitempty"`
+	Parameters      map[string]*Parameter      `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	Responses       map[string]*Response       `yaml:"responses,omitempty" json:"responses,omitempty"`
+	Headers         map[string]*Header         `yaml:"headers,omitempty" json:"headers,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `yaml:"securitySchemes,omitempty" json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `yaml:"type" json:"type"`
+	Description  string `yaml:"description,omitempty" json:"description,omitempty"`
+	Name         string `yaml:"name,omitempty" json:"name,omitempty"`
+	In           string `yaml:"in,omitempty" json:"in,omitempty"`
+	Scheme       string `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	BearerFormat string `yaml:"bearerFormat,omitempty" json:"bearerFormat,omitempty"`
+}
+
+// Load: parses the embedded document, every call returns a new copy that can be changed by the caller
+func Load() (*Document, error) {
+	doc := &Document{}
+	// an unknown field is most likely a typo, which would be left out of the document silently
+	decoder := yaml.NewDecoder(bytes.NewReader(spec))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(doc); err != nil {
+		return nil, fmt.Errorf("cannot parse the openapi document: %v", err)
+	}
+	return doc, nil
+}
+
+// Operation: returns the operation of the method on the path template, nil if it is not documented
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+// Parameter: resolves a reference to a parameter of the components
+func (d *Document) Parameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	resolved, ok := d.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %s", p.Ref)
+	}
+	return resolved, nil
+}
+
+// Response: resolves a reference to a response of the components
+func (d *Document) Response(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	resolved, ok := d.Components.Responses[strings.TrimPrefix(r.Ref, "#/components/responses/")]
+	if !ok {
+		return nil, fmt.Errorf("unknown response %s", r.Ref)
+	}
+	return resolved, nil
+}
+
+// Schema: resolves a reference to a schema of the components
+func (d *Document) Schema(s *Schema) (*Schema, error) {
+	if s.Ref == "" {
+		return s, nil
+	}
+	resolved, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %s", s.Ref)
+	}
+	return resolved, nil
+}
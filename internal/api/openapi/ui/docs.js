@@ -0,0 +1,158 @@
+// Renders the OpenAPI document of the api, the operations are grouped by their first tag and the schemas follow them.
+"use strict";
+
+const methods = ["get", "post", "put", "patch", "delete"];
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    node.setAttribute(key, value);
+  }
+  for (const child of children) {
+    if (child !== null && child !== undefined) {
+      node.append(child);
+    }
+  }
+  return node;
+}
+
+// text: a paragraph of the markdown subset of the document, the `code` spans are rendered as code
+function text(tag, markdown) {
+  const node = el(tag);
+  markdown.split("`").forEach((part, i) => node.append(i % 2 === 1 ? el("code", {}, part) : part));
+  return node;
+}
+
+function paragraphs(markdown) {
+  return (markdown || "").split(/\n\s*\n/).filter((p) => p.trim() !== "").map((p) => text("p", p.trim()));
+}
+
+function refName(ref) {
+  return ref.substring(ref.lastIndexOf("/") + 1);
+}
+
+function resolve(doc, kind, item) {
+  return item && item.$ref ? doc.components[kind][refName(item.$ref)] : item;
+}
+
+// typeOf: a short description of a schema, e.g. Book[] or string (date-time)
+function typeOf(schema) {
+  if (!schema) {
+    return "";
+  }
+  if (schema.$ref) {
+    return refName(schema.$ref);
+  }
+  if (schema.oneOf) {
+    return schema.oneOf.map(typeOf).join(" | ");
+  }
+  if (schema.type === "array") {
+    return typeOf(schema.items) + "[]";
+  }
+  if (schema.additionalProperties) {
+    return "map of " + typeOf(schema.additionalProperties);
+  }
+  let type = schema.type || "any";
+  if (schema.format) {
+    type += " (" + schema.format + ")";
+  }
+  if (schema.enum) {
+    type += ": " + schema.enum.join(", ");
+  }
+  if (schema.nullable) {
+    type += ", nullable";
+  }
+  return type;
+}
+
+function table(head, rows) {
+  return el("table", {},
+    el("thead", {}, el("tr", {}, ...head.map((h) => el("th", {}, h)))),
+    el("tbody", {}, ...rows.map((row) => el("tr", {}, ...row.map((cell) => el("td", {}, cell))))));
+}
+
+function operation(doc, path, method, op) {
+  const section = el("section", {class: "operation" + (op.deprecated ? " deprecated" : ""), id: op.operationId},
+    el("h3", {},
+      el("span", {class: "method " + method}, method),
+      el("span", {class: "path"}, path),
+      op["x-permission"] ? el("span", {class: "badge"}, op["x-permission"]) : null,
+      op["x-rate-limit-group"] ? el("span", {class: "badge"}, "limit: " + op["x-rate-limit-group"]) : null,
+      op.deprecated ? el("span", {class: "badge"}, "deprecated") : null),
+    op.summary ? text("p", op.summary) : null,
+    ...paragraphs(op.description));
+
+  const params = (op.parameters || []).map((p) => resolve(doc, "parameters", p));
+  if (params.length > 0) {
+    section.append(el("h4", {}, "Parameters"), table(["Name", "In", "Type", "Required", "Description"],
+      params.map((p) => [p.name, p.in, typeOf(p.schema), p.required ? "yes" : "", p.description || ""])));
+  }
+  if (op.requestBody) {
+    section.append(el("h4", {}, "Request body"), table(["Content type", "Schema"],
+      Object.entries(op.requestBody.content).map(([type, media]) => [type, typeOf(media.schema)])));
+  }
+  const responses = Object.entries(op.responses).sort(([a], [b]) => a.localeCompare(b)).map(([code, r]) => {
+    const response = resolve(doc, "responses", r);
+    const content = Object.entries(response.content || {}).map(([type, media]) => type + ": " + typeOf(media.schema));
+    const headers = Object.keys(response.headers || {}).map((h) => "header " + h);
+    return [code, response.description || "", content.concat(headers).join("; ")];
+  });
+  section.append(el("h4", {}, "Responses"), table(["Status", "Description", "Content"], responses));
+  return section;
+}
+
+function schema(name, s) {
+  const required = new Set(s.required || []);
+  const section = el("section", {class: "operation", id: "schema-" + name}, el("h3", {}, el("span", {class: "path"}, name)),
+    ...paragraphs(s.description));
+  if (s.properties) {
+    section.append(table(["Property", "Type", "Required", "Description"],
+      Object.entries(s.properties).map(([prop, p]) => [prop, typeOf(p), required.has(prop) ? "yes" : "", p.description || ""])));
+  } else {
+    section.append(el("p", {}, typeOf(s)));
+  }
+  return section;
+}
+
+function render(doc) {
+  document.title = doc.info.title;
+  document.getElementById("title").textContent = doc.info.title + " " + doc.info.version;
+  const main = document.getElementById("docs");
+  const toc = document.getElementById("toc");
+  main.replaceChildren(...paragraphs(doc.info.description));
+
+  const byTag = new Map((doc.tags || []).map((t) => [t.name, []]));
+  for (const path of Object.keys(doc.paths).sort()) {
+    for (const method of methods) {
+      const op = doc.paths[path][method];
+      if (op) {
+        const tag = (op.tags || ["other"])[0];
+        if (!byTag.has(tag)) {
+          byTag.set(tag, []);
+        }
+        byTag.get(tag).push(operation(doc, path, method, op));
+      }
+    }
+  }
+  for (const [tag, sections] of byTag) {
+    const info = (doc.tags || []).find((t) => t.name === tag);
+    toc.append(el("a", {href: "#tag-" + tag}, tag));
+    main.append(el("h2", {id: "tag-" + tag}, tag), info && info.description ? text("p", info.description) : null, ...sections);
+  }
+
+  toc.append(el("a", {href: "#schemas"}, "schemas"));
+  main.append(el("h2", {id: "schemas"}, "schemas"));
+  for (const name of Object.keys(doc.components.schemas || {}).sort()) {
+    main.append(schema(name, doc.components.schemas[name]));
+  }
+}
+
+fetch("../openapi.json")
+  .then((response) => {
+    if (!response.ok) {
+      throw new Error("the document cannot be loaded: " + response.status);
+    }
+    return response.json();
+  })
+  .then(render)
+  .catch((err) => document.getElementById("docs").replaceChildren(el("p", {}, err.message)));
@@ -0,0 +1,213 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateResponse: checks a response of the operation of the method on the path template against the document
+// the status must be documented, the required headers must be set and the body must have a documented content type,
+// json bodies must match the schema of their content type
+func (d *Document) ValidateResponse(method, path string, status int, header http.Header, body []byte) error {
+	op := d.Operation(method, path)
+	if op == nil {
+		return fmt.Errorf("operation %s %s is not documented", method, path)
+	}
+	documented, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("status %d is not documented", status)
+	}
+	response, err := d.Response(documented)
+	if err != nil {
+		return err
+	}
+	for name, h := range response.Headers {
+		if h.Ref != "" {
+			continue
+		}
+		if h.Required && header.Get(name) == "" {
+			return fmt.Errorf("header %s is missing", name)
+		}
+	}
+
+	if len(response.Content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("body is not documented, got %d bytes", len(body))
+		}
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("content type %q cannot be parsed: %v", header.Get("Content-Type"), err)
+	}
+	media, ok := response.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("content type %s is not documented", mediaType)
+	}
+	if mediaType != "application/json" || media.Schema == nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("body is not json: %v", err)
+	}
+	return d.Validate(media.Schema, value)
+}
+
+// Validate: checks a decoded json value against the schema
+// an object with documented properties is closed, a property that is not documented is an error,
+// so a field added to or renamed in a response is caught as well as a removed one
+func (d *Document) Validate(schema *Schema, value interface{}) error {
+	return d.validate("body", schema, value)
+}
+
+func (d *Document) validate(at string, schema *Schema, value interface{}) error {
+	schema, err := d.Schema(schema)
+	if err != nil {
+		return fmt.Errorf("%s: %v", at, err)
+	}
+	if value == nil {
+		if schema.Nullable || (schema.Type == "" && len(schema.OneOf) == 0) {
+			return nil
+		}
+		return fmt.Errorf("%s: is null", at)
+	}
+	if len(schema.OneOf) > 0 {
+		return d.validateOneOf(at, schema, value)
+	}
+
+	switch schema.Type {
+	case "":
+		return nil
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(at, schema.Type, value)
+		}
+		return d.validateObject(at, schema, object)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return typeError(at, schema.Type, value)
+		}
+		if schema.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := d.validate(fmt.Sprintf("%s[%d]", at, i), schema.Items, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return typeError(at, schema.Type, value)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", at, s)
+			}
+		}
+		return validateEnum(at, schema, s)
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok || (schema.Type == "integer" && n != math.Trunc(n)) {
+			return typeError(at, schema.Type, value)
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			return fmt.Errorf("%s: %v is less than %v", at, n, *schema.Minimum)
+		}
+		return nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(at, schema.Type, value)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: unknown type %s in the schema", at, schema.Type)
+}
+
+func (d *Document) validateObject(at string, schema *Schema, object map[string]interface{}) error {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s: property %s is missing", at, name)
+		}
+	}
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		switch {
+		case ok:
+		case schema.AdditionalProperties != nil:
+			property = schema.AdditionalProperties
+		case schema.Properties == nil:
+			// a free form object, e.g. the details of a health check
+			continue
+		default:
+			return fmt.Errorf("%s: property %s is not documented", at, name)
+		}
+		if err := d.validate(at+"."+name, property, object[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOneOf: the value must match exactly one of the schemas
+func (d *Document) validateOneOf(at string, schema *Schema, value interface{}) error {
+	matched := 0
+	errs := []string{}
+	for _, s := range schema.OneOf {
+		if err := d.validate(at, s, value); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		matched++
+	}
+	switch matched {
+	case 1:
+		return nil
+	case 0:
+		return fmt.Errorf("%s: matches none of the schemas: %s", at, strings.Join(errs, "; "))
+	}
+	return fmt.Errorf("%s: matches %d of the schemas, it must match one", at, matched)
+}
+
+func validateEnum(at string, schema *Schema, s string) error {
+	if len(schema.Enum) == 0 {
+		return nil
+	}
+	for _, e := range schema.Enum {
+		if e == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %q is not one of %s", at, s, strings.Join(schema.Enum, ", "))
+}
+
+func typeError(at, want string, value interface{}) error {
+	got := "object"
+	switch value.(type) {
+	case []interface{}:
+		got = "array"
+	case string:
+		got = "string"
+	case float64:
+		got = "number"
+	case bool:
+		got = "boolean"
+	}
+	return fmt.Errorf("%s: is %s, want %s", at, got, want)
+}
@@ -0,0 +1,149 @@
+package router
+
+import (
+	"bookApp/internal/api/router/httpErrors"
+	"bookApp/internal/auth"
+	"bookApp/pkg/logger"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Auth: identifies the callers of the requests, requests without credentials are anonymous
+var Auth *auth.Authenticator
+
+// routePermissions: the permission each route requires, declared with permit when the route is registered
+var routePermissions = map[*mux.Route]auth.Permission{}
+
+// permit: declares the permission required to call the route
+func permit(p auth.Permission, route *mux.Route) *mux.Route {
+	routePermissions[route] = p
+	return route
+}
+
+// Authenticate: puts the principal of the credentials of the request into the request context
+// a request with invalid credentials is rejected even if its route does not require authentication
+// the failed authentications are charged to the bucket of the client ip in the write group, so guessing credentials is limited
+// like anonymous writes on every route, the unlimited probes included
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := Auth.Authenticate(r)
+		if err != nil {
+			if throttle(w, r, failedAuthGroup, clientCaller(r)) {
+				return
+			}
+			unauthorized(w, err)
+			return
+		}
+		if principal != nil {
+			ctx := auth.NewContext(r.Context(), principal)
+			fields := []interface{}{"subject", principal.Subject, "auth", principal.Method, "roles", strings.Join(principal.RoleNames(), ",")}
+			if principal.Name != "" {
+				fields = append(fields, "name", principal.Name)
+			}
+			ctx = logger.NewContext(ctx, logger.FromContext(ctx).With(fields...))
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Authorize: checks the permission declared for the matched route, anonymous callers of a protected route get 401
+// and callers without a role having the permission get 403
+func Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		permission, ok := routePermissions[mux.CurrentRoute(r)]
+		if !ok {
+			// routes are checked by Handle, an undeclared route is never public
+			forbidden(w, fmt.Errorf("route %s has no permission declaration", routeTemplate(r)))
+			return
+		}
+		if authorized(w, r, permission) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// authorized: reports whether the caller has the permission, the anonymous callers of a protected permission are answered
+// with 401 and the callers without a role having it with 403, e.g. for a query of a public route that needs a permission
+func authorized(w http.ResponseWriter, r *http.Request, permission auth.Permission) bool {
+	if permission == auth.PermPublic {
+		return true
+	}
+	principal := auth.FromContext(r.Context())
+	if principal == nil {
+		unauthorized(w, auth.ErrMissingCredentials)
+		return false
+	}
+	if !principal.Can(permission) {
+		forbidden(w, fmt.Errorf("%w: %s requires one of the roles %s", auth.ErrForbidden, permission, rolesWith(permission)))
+		return false
+	}
+	return true
+}
+
+// unauthorized: responds 401 in the standard error format with the accepted authentication schemes
+func unauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="bookApp"`)
+	respondWithError(w, httpErrors.NewApiError(http.StatusUnauthorized, httpErrors.Unauthorized.Error(), err.Error()))
+}
+
+// forbidden: responds 403 in the standard error format
+func forbidden(w http.ResponseWriter, err error) {
+	respondWithError(w, httpErrors.NewApiError(http.StatusForbidden, httpErrors.Forbidden.Error(), err.Error()))
+}
+
+func rolesWith(p auth.Permission) string {
+	names := []string{}
+	for _, r := range auth.RolesWith(p) {
+		names = append(names, string(r))
+	}
+	return strings.Join(names, ", ")
+}
+
+// RoutePermission: a route of the router with the permission it requires
+type RoutePermission struct {
+	Name       string
+	Methods    []string
+	Path       string
+	Queries    []string
+	Permission auth.Permission
+	Roles      []auth.Role
+}
+
+// RoutePermissions: returns the permission matrix of the routes registered on the router
+func RoutePermissions(mr *mux.Router) ([]RoutePermission, error) {
+	routes := []RoutePermission{}
+	err := mr.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			// path prefixes of the subrouters
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, _ := route.GetMethods()
+		queries, _ := route.GetQueriesTemplates()
+		permission, ok := routePermissions[route]
+		if !ok {
+			return fmt.Errorf("route %s %s has no permission declaration", strings.Join(methods, ","), path)
+		}
+		routes = append(routes, RoutePermission{Name: route.GetName(), Methods: methods, Path: path, Queries: queries, Permission: permission, Roles: auth.RolesWith(permission)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+	return routes, nil
+}
+
+// checkPermissions: returns an error if a route of the router has no permission declaration
+func checkPermissions(mr *mux.Router) error {
+	_, err := RoutePermissions(mr)
+	return err
+}
@@ -0,0 +1,147 @@
+package router
+
+import (
+	"bookApp/internal/auth"
+	"net/http"
+)
+
+// contractCases: a case of every documented operation with its error responses, the reads come before the changes
+// the cases are written for the sample data, e.g. the book 1 and the author 101 exist in it
+func contractCases() []contractCase {
+	const (
+		get   = http.MethodGet
+		post  = http.MethodPost
+		patch = http.MethodPatch
+		put   = http.MethodPut
+		del   = http.MethodDelete
+	)
+	reader, clerk, manager, admin := auth.RoleReader, auth.RoleClerk, auth.RoleInventoryManager, auth.RoleAdmin
+
+	return []contractCase{
+		// not versioned
+		{Name: "home", Method: get, URI: "/", Status: http.StatusOK},
+		{Name: "liveness", Method: get, URI: "/healthz", Status: http.StatusOK},
+		{Name: "readiness", Method: get, URI: "/readyz", Status: http.StatusOK},
+		{Name: "metrics", Method: get, URI: "/metrics", Status: http.StatusOK},
+		{Name: "openapi document", Method: get, URI: "/openapi.json", Status: http.StatusOK},
+		{Name: "docs redirect", Method: get, URI: "/docs", Status: http.StatusMovedPermanently},
+		{Name: "docs page", Method: get, URI: "/docs/", Status: http.StatusOK},
+		{Name: "docs file not found", Method: get, URI: "/docs/missing.js", Status: http.StatusNotFound},
+
+		// v1 reads
+		{Name: "list books", Method: get, URI: "/v1/books/", Status: http.StatusOK},
+		{Name: "list books including deleted", Method: get, URI: "/v1/books/all", Role: clerk, Status: http.StatusOK},
+		{Name: "list books including deleted anonymously", Method: get, URI: "/v1/books/all", Status: http.StatusUnauthorized},
+		{Name: "list books including deleted as a reader", Method: get, URI: "/v1/books/all", Role: reader, Status: http.StatusForbidden},
+		{Name: "list books in stock", Method: get, URI: "/v1/books/stock", Status: http.StatusOK},
+		{Name: "list books under price", Method: get, URI: "/v1/books/price/20", Status: http.StatusOK},
+		{Name: "no book under price", Method: get, URI: "/v1/books/price/1", Status: http.StatusNotFound},
+		{Name: "list books under an invalid price", Method: get, URI: "/v1/books/price/cheap", Status: http.StatusBadRequest},
+		{Name: "book by id", Method: get, URI: "/v1/books?id=1", Status: http.StatusOK},
+		{Name: "book by unknown id", Method: get, URI: "/v1/books?id=999", Status: http.StatusNotFound},
+		{Name: "book by isbn", Method: get, URI: "/v1/books?isbn=9780547928227", Status: http.StatusOK},
+		{Name: "book by unknown isbn", Method: get, URI: "/v1/books?isbn=9780000000000", Status: http.StatusNotFound},
+		{Name: "books by name", Method: get, URI: "/v1/books?name=the", Status: http.StatusOK},
+		{Name: "list authors with books", Method: get, URI: "/v1/authors/", Status: http.StatusOK},
+		{Name: "list authors", Method: get, URI: "/v1/authors/*", Status: http.StatusOK},
+		{Name: "author by id", Method: get, URI: "/v1/authors?id=101", Status: http.StatusOK},
+		{Name: "author by unknown id", Method: get, URI: "/v1/authors?id=999", Status: http.StatusNotFound},
+		{Name: "authors by name", Method: get, URI: "/v1/authors?name=j.", Status: http.StatusOK},
+		{Name: "books of authors by name", Method: get, URI: "/v1/authors/books?name=antoine", Status: http.StatusOK},
+		{Name: "export books", Method: get, URI: "/v1/export/books", Role: clerk, Status: http.StatusOK},
+		{Name: "export books as json lines", Method: get, URI: "/v1/export/books?format=ndjson&deleted=true", Role: clerk, Status: http.StatusOK},
+		{Name: "export books as onix", Method: get, URI: "/v1/export/books?format=onix", Role: clerk, Status: http.StatusOK},
+		{Name: "export books as xlsx", Method: get, URI: "/v1/export/books?format=xlsx", Role: clerk, Status: http.StatusOK},
+		{Name: "export in an unknown format", Method: get, URI: "/v1/export/books?format=pdf", Role: clerk, Status: http.StatusBadRequest},
+		{Name: "export anonymously", Method: get, URI: "/v1/export/books", Status: http.StatusUnauthorized},
+		{Name: "export as a reader", Method: get, URI: "/v1/export/books", Role: reader, Status: http.StatusForbidden},
+		{Name: "export authors", Method: get, URI: "/v1/export/authors", Role: clerk, Status: http.StatusOK},
+		{Name: "audit trail", Method: get, URI: "/v1/audit?entity=book&id=1", Role: manager, Status: http.StatusOK},
+		{Name: "audit trail of an unknown entity", Method: get, URI: "/v1/audit?entity=shelf&id=1", Role: manager, Status: http.StatusBadRequest},
+		{Name: "audit trail without permission", Method: get, URI: "/v1/audit?entity=book&id=1", Role: reader, Status: http.StatusForbidden},
+
+		// v1 changes
+		{Name: "add book", Method: post, URI: "/v1/books/add", Role: manager, Status: http.StatusOK,
+			Body: `{"ID":"11","name":"Utopia","pageNumber":182,"stockNumber":20,"stockId":"11SF","price":14.7,"isbn":"9781128355898","authorID":"909","Author":{"ID":"909","name":"Thomas Moore"}}`},
+		{Name: "add book with the stock id of another book", Method: post, URI: "/v1/books/add", Role: manager, Status: http.StatusConflict,
+			Body: `{"ID":"14","name":"Emma","stockId":"11SF","authorID":"909"}`},
+		{Name: "add book with invalid json", Method: post, URI: "/v1/books/add", Role: manager, Body: `{"ID":`, Status: http.StatusBadRequest},
+		{Name: "add book anonymously", Method: post, URI: "/v1/books/add", Body: `{}`, Status: http.StatusUnauthorized},
+		{Name: "add book without permission", Method: post, URI: "/v1/books/add", Role: clerk, Body: `{}`, Status: http.StatusForbidden},
+		{Name: "order book", Method: patch, URI: "/v1/books/order?id=2&quantity=1", Role: clerk, Status: http.StatusOK},
+		{Name: "order more than the stock", Method: patch, URI: "/v1/books/order?id=2&quantity=100", Role: clerk, Status: http.StatusConflict},
+		{Name: "order unknown book", Method: patch, URI: "/v1/books/order?id=999&quantity=1", Role: clerk, Status: http.StatusNotFound},
+		{Name: "order no book", Method: patch, URI: "/v1/books/order?id=2&quantity=0", Role: clerk, Status: http.StatusBadRequest},
+		{Name: "order a negative quantity", Method: patch, URI: "/v1/books/order?id=2&quantity=-5", Role: clerk, Status: http.StatusBadRequest},
+		{Name: "delete book", Method: del, URI: "/v1/books/delete?id=5", Role: admin, Status: http.StatusOK},
+		{Name: "delete unknown book", Method: del, URI: "/v1/books/delete?id=999", Role: admin, Status: http.StatusNotFound},
+		{Name: "delete book without permission", Method: del, URI: "/v1/books/delete?id=5", Role: manager, Status: http.StatusForbidden},
+
+		// v2 reads
+		{Name: "list books", Method: get, URI: "/v2/books", Status: http.StatusOK},
+		{Name: "list books in stock", Method: get, URI: "/v2/books?inStock=true", Status: http.StatusOK},
+		{Name: "list books including deleted", Method: get, URI: "/v2/books?deleted=true", Role: clerk, Status: http.StatusOK},
+		{Name: "list books including deleted anonymously", Method: get, URI: "/v2/books?deleted=true", Status: http.StatusUnauthorized},
+		{Name: "list books including deleted as a reader", Method: get, URI: "/v2/books?deleted=true", Role: reader, Status: http.StatusForbidden},
+		{Name: "list books with the deletion times", Method: get, URI: "/v2/books?deleted=true", Role: manager, Status: http.StatusOK},
+		{Name: "list books with an invalid filter", Method: get, URI: "/v2/books?inStock=maybe", Status: http.StatusBadRequest},
+		{Name: "list books with both filters", Method: get, URI: "/v2/books?inStock=true&deleted=true", Status: http.StatusBadRequest},
+		{Name: "list books under price", Method: get, URI: "/v2/books?maxPrice=20", Status: http.StatusOK},
+		{Name: "no book under price", Method: get, URI: "/v2/books?maxPrice=1", Status: http.StatusOK},
+		{Name: "list books under an invalid price", Method: get, URI: "/v2/books?maxPrice=cheap", Status: http.StatusBadRequest},
+		{Name: "list books under a price that is not a number", Method: get, URI: "/v2/books?maxPrice=NaN", Status: http.StatusBadRequest},
+		{Name: "list books under an infinite price", Method: get, URI: "/v2/books?maxPrice=Inf", Status: http.StatusBadRequest},
+		{Name: "book by isbn", Method: get, URI: "/v2/books?isbn=9780547928227", Status: http.StatusOK},
+		{Name: "book by unknown isbn", Method: get, URI: "/v2/books?isbn=9780000000000", Status: http.StatusNotFound},
+		{Name: "books by name", Method: get, URI: "/v2/books?name=the", Status: http.StatusOK},
+		{Name: "book", Method: get, URI: "/v2/books/1", Status: http.StatusOK},
+		{Name: "book with the stock id", Method: get, URI: "/v2/books/1", Role: clerk, Status: http.StatusOK},
+		{Name: "unknown book", Method: get, URI: "/v2/books/999", Status: http.StatusNotFound},
+		{Name: "list authors", Method: get, URI: "/v2/authors", Status: http.StatusOK},
+		{Name: "authors by name", Method: get, URI: "/v2/authors?name=j.", Status: http.StatusOK},
+		{Name: "author", Method: get, URI: "/v2/authors/101", Status: http.StatusOK},
+		{Name: "unknown author", Method: get, URI: "/v2/authors/999", Status: http.StatusNotFound},
+		{Name: "books of author", Method: get, URI: "/v2/authors/101/books", Status: http.StatusOK},
+		{Name: "books of unknown author", Method: get, URI: "/v2/authors/999/books", Status: http.StatusNotFound},
+		{Name: "export books", Method: get, URI: "/v2/export/books", Role: clerk, Status: http.StatusOK},
+		{Name: "export authors", Method: get, URI: "/v2/export/authors", Role: clerk, Status: http.StatusOK},
+		{Name: "audit trail", Method: get, URI: "/v2/audit?entity=author&id=909", Role: admin, Status: http.StatusOK},
+		{Name: "audit trail without an id", Method: get, URI: "/v2/audit?entity=book&id=", Role: admin, Status: http.StatusBadRequest},
+
+		// v2 changes
+		{Name: "create book", Method: post, URI: "/v2/books", Role: manager, Status: http.StatusCreated,
+			Body: `{"id":"12","name":"The Island","pageNumber":140,"stockNumber":5,"stockId":"12SF","price":9.5,"isbn":"9781128355899","authorId":"909"}`},
+		{Name: "create book with a new author", Method: post, URI: "/v2/books", Role: manager, Status: http.StatusCreated,
+			Body: `{"id":"13","name":"Candide","pageNumber":144,"stockNumber":3,"stockId":"13SF","price":6.2,"isbn":"9780140440041","author":{"id":"910","name":"Voltaire"}}`},
+		{Name: "create existing book", Method: post, URI: "/v2/books", Role: manager, Status: http.StatusConflict,
+			Body: `{"id":"12","name":"The Island","stockId":"14SF","authorId":"909"}`},
+		{Name: "create book with the stock id of another book", Method: post, URI: "/v2/books", Role: manager, Status: http.StatusConflict,
+			Body: `{"id":"14","name":"Emma","stockId":"12SF","authorId":"909"}`},
+		{Name: "create book without name", Method: post, URI: "/v2/books", Role: manager, Body: `{"id":"14"}`, Status: http.StatusBadRequest},
+		{Name: "create book with an unknown field", Method: post, URI: "/v2/books", Role: manager, Body: `{"id":"14","name":"Emma","CreatedAt":"2020-01-01T00:00:00Z"}`, Status: http.StatusBadRequest},
+		{Name: "create book with a negative stock", Method: post, URI: "/v2/books", Role: manager, Body: `{"id":"14","name":"Emma","stockNumber":-1}`, Status: http.StatusBadRequest},
+		{Name: "create book anonymously", Method: post, URI: "/v2/books", Body: `{}`, Status: http.StatusUnauthorized},
+		{Name: "create book without permission", Method: post, URI: "/v2/books", Role: clerk, Body: `{}`, Status: http.StatusForbidden},
+		{Name: "order book", Method: post, URI: "/v2/books/12/orders", Role: clerk, Body: `{"quantity":2}`, Status: http.StatusOK},
+		{Name: "order no book", Method: post, URI: "/v2/books/12/orders", Role: clerk, Body: `{"quantity":0}`, Status: http.StatusBadRequest},
+		{Name: "order a negative quantity", Method: post, URI: "/v2/books/12/orders", Role: clerk, Body: `{"quantity":-5}`, Status: http.StatusBadRequest},
+		{Name: "order more than the stock", Method: post, URI: "/v2/books/12/orders", Role: clerk, Body: `{"quantity":100}`, Status: http.StatusConflict},
+		{Name: "order unknown book", Method: post, URI: "/v2/books/999/orders", Role: clerk, Body: `{"quantity":1}`, Status: http.StatusNotFound},
+		{Name: "order without permission", Method: post, URI: "/v2/books/12/orders", Role: reader, Body: `{"quantity":1}`, Status: http.StatusForbidden},
+		{Name: "adjust stock", Method: put, URI: "/v2/books/12/stock", Role: manager, Body: `{"stockNumber":8}`, Status: http.StatusOK},
+		{Name: "adjust stock to a negative number", Method: put, URI: "/v2/books/12/stock", Role: manager, Body: `{"stockNumber":-1}`, Status: http.StatusBadRequest},
+		{Name: "adjust stock without a number", Method: put, URI: "/v2/books/12/stock", Role: manager, Body: `{}`, Status: http.StatusBadRequest},
+		{Name: "adjust stock of unknown book", Method: put, URI: "/v2/books/999/stock", Role: manager, Body: `{"stockNumber":1}`, Status: http.StatusNotFound},
+		{Name: "adjust stock without permission", Method: put, URI: "/v2/books/12/stock", Role: clerk, Body: `{"stockNumber":1}`, Status: http.StatusForbidden},
+		{Name: "delete book", Method: del, URI: "/v2/books/4", Role: admin, Status: http.StatusNoContent},
+		{Name: "delete unknown book", Method: del, URI: "/v2/books/999", Role: admin, Status: http.StatusNotFound},
+		{Name: "restore book", Method: post, URI: "/v2/books/5/restore", Role: admin, Status: http.StatusOK},
+		{Name: "restore book that is not deleted", Method: post, URI: "/v2/books/5/restore", Role: admin, Status: http.StatusConflict},
+		{Name: "restore unknown book", Method: post, URI: "/v2/books/999/restore", Role: admin, Status: http.StatusNotFound},
+		{Name: "restore book without permission", Method: post, URI: "/v2/books/4/restore", Role: manager, Status: http.StatusForbidden},
+		{Name: "purge book", Method: post, URI: "/v2/books/4/purge", Role: admin, Status: http.StatusNoContent},
+		{Name: "purge book that is not deleted", Method: post, URI: "/v2/books/1/purge", Role: admin, Status: http.StatusConflict},
+		{Name: "purge unknown book", Method: post, URI: "/v2/books/999/purge", Role: admin, Status: http.StatusNotFound},
+		{Name: "purge book without permission", Method: post, URI: "/v2/books/4/purge", Role: manager, Status: http.StatusForbidden},
+	}
+}
@@ -0,0 +1,138 @@
+package router
+
+import (
+	"bookApp/internal/api/openapi"
+	"bookApp/internal/auth"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// contractCase: a request driven through the router and the status it must be answered with
+type contractCase struct {
+	Name   string
+	Method string
+	// URI: the path and the query of the request
+	URI string
+	// Role: the role of the bearer token sent with the request, the request is anonymous without a role
+	Role   auth.Role
+	Body   string
+	Status int
+}
+
+// TestContract: drives the cases through the router against the in-memory database seeded with the sample data
+// and validates the status, the headers and the body of every response against the openapi document
+// the cases run in their order, a case may depend on the changes of the cases before it
+// it fails if a response does not match or a documented operation has no case, a change of a response must update
+// the document and a new route must get its cases, e.g.
+//
+//	go test ./internal/api/router -run Contract -v
+func TestContract(t *testing.T) {
+	mr, tokens := newTestRouter(t)
+	doc, err := OpenAPI(mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := contractCases()
+	cases = append(cases, aliasCases(cases)...)
+	covered := map[string]bool{}
+	for _, c := range cases {
+		t.Run(c.Method+" "+c.URI+" "+c.Name, func(t *testing.T) {
+			route, err := runCase(mr, doc, tokens, c)
+			if route != "" {
+				covered[strings.ToLower(c.Method)+" "+route] = true
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	if uncovered := uncoveredOperations(doc, covered); len(uncovered) > 0 {
+		t.Errorf("the operations have no case:\n%s", strings.Join(uncovered, "\n"))
+	}
+}
+
+// runCase: sends the request of the case as the role of the case, the path template of the matched route is returned
+// with the error of a response that does not have the status of the case or does not match the document
+func runCase(mr *mux.Router, doc *openapi.Document, tokens *auth.TokenIssuer, c contractCase) (string, error) {
+	req := httptest.NewRequest(c.Method, c.URI, strings.NewReader(c.Body))
+	if c.Body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Role != "" {
+		token, err := tokens.Issue("contract-"+string(c.Role), []auth.Role{c.Role}, time.Minute)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	var match mux.RouteMatch
+	if !mr.Match(req, &match) || match.Route == nil {
+		return "", fmt.Errorf("no route matches")
+	}
+	route, _ := match.Route.GetPathTemplate()
+
+	rec := httptest.NewRecorder()
+	mr.ServeHTTP(rec, req)
+	if rec.Code != c.Status {
+		return route, fmt.Errorf("status %d, want %d: %s", rec.Code, c.Status, truncate(rec.Body.String(), 200))
+	}
+	if err := doc.ValidateResponse(c.Method, route, rec.Code, rec.Header(), rec.Body.Bytes()); err != nil {
+		return route, err
+	}
+	return route, checkHeaders(doc.Operation(c.Method, route), rec.Header())
+}
+
+// checkHeaders: the headers every response has and the ones of the deprecated aliases
+func checkHeaders(op *openapi.Operation, h http.Header) error {
+	if h.Get("X-Content-Type-Options") != "nosniff" || h.Get("X-Request-Id") == "" {
+		return fmt.Errorf("security or request id headers are missing")
+	}
+	if op.Deprecated && (h.Get("Deprecation") == "" || h.Get("Sunset") == "" || h.Get("Link") == "") {
+		return fmt.Errorf("deprecation headers of the alias are missing")
+	}
+	return nil
+}
+
+// aliasCases: the cases of the v1 reads run against the deprecated root aliases
+func aliasCases(cases []contractCase) []contractCase {
+	aliases := []contractCase{}
+	for _, c := range cases {
+		if c.Method == http.MethodGet && strings.HasPrefix(c.URI, "/v1/") {
+			c.Name = "alias: " + c.Name
+			c.URI = strings.TrimPrefix(c.URI, "/v1")
+			aliases = append(aliases, c)
+		}
+	}
+	return aliases
+}
+
+// uncoveredOperations: the documented operations no case was run against, the deprecated aliases are covered by their v1 operations
+func uncoveredOperations(doc *openapi.Document, covered map[string]bool) []string {
+	uncovered := []string{}
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if !op.Deprecated && !covered[method+" "+path] {
+				uncovered = append(uncovered, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(uncovered)
+	return uncovered
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
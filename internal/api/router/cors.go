@@ -0,0 +1,61 @@
+package router
+
+import (
+	"bookApp/internal/cors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// CORS: the origins allowed to call the api from a browser, nil disables CORS
+// HSTSMaxAge: how long browsers only connect over https once they were served over tls, 0 omits the header
+var (
+	CORS       *cors.Policy
+	HSTSMaxAge = 365 * 24 * time.Hour
+)
+
+// CrossOrigin: adds the CORS headers to the responses of the requests of the allowed origins
+// it runs before the authentication, so that a browser can read the 401 and 403 responses as well
+func CrossOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if CORS != nil {
+			// the response depends on the origin, caches must not serve it to another one
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); CORS.AllowOrigin(origin) {
+				CORS.Actual(w.Header(), origin)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Preflight: answers the OPTIONS requests, the preflight requests of the allowed origins get the allowed methods and headers
+func Preflight(w http.ResponseWriter, r *http.Request) {
+	if CORS != nil && r.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		CORS.Preflight(w.Header(), r.Header.Get("Origin"), r.Header.Get("Access-Control-Request-Method"), r.Header.Get("Access-Control-Request-Headers"))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func isOptions(r *http.Request, _ *mux.RouteMatch) bool {
+	return r.Method == http.MethodOptions
+}
+
+// SecureHeaders: sets the security headers of an api serving json only, HSTS is only sent over tls
+func SecureHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		if r.TLS != nil && HSTSMaxAge > 0 {
+			h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(HSTSMaxAge.Seconds())))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,27 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Deadlines of the database queries of a request, they must be set before Handle is called.
+// Once a deadline passes the query is cancelled and a timeout error is returned, 0 disables the deadline.
+var (
+	QueryTimeout  = 10 * time.Second
+	SearchTimeout = 5 * time.Second
+)
+
+// withDeadline: runs the handler with a request context that is cancelled after the given duration
+// the context is cancelled as well when the client disconnects
+func withDeadline(d time.Duration, h http.HandlerFunc) http.HandlerFunc {
+	if d <= 0 {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		h(w, r.WithContext(ctx))
+	}
+}
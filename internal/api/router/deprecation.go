@@ -0,0 +1,40 @@
+package router
+
+import (
+	"bookApp/internal/metrics"
+	"bookApp/pkg/logger"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// legacyPrefix: the version whose routes are served at the root as deprecated aliases
+const legacyPrefix = "/v1"
+
+// legacyDeprecatedAt: when the root aliases were deprecated, the release mounting the api under /v1
+var legacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// LegacySunset: when the root aliases are removed, it must be set before Handle is called
+var LegacySunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+// legacyRoutes: the routes registered as deprecated aliases of the v1 routes at the root
+var legacyRoutes = map[*mux.Route]bool{}
+
+// DeprecatedAlias: marks the responses of the deprecated aliases with the Deprecation and Sunset headers
+// and links the same request under the legacy prefix as its successor
+// it runs before the authentication so the rejected requests of an alias are marked as well
+func DeprecatedAlias(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if legacyRoutes[mux.CurrentRoute(r)] {
+			h := w.Header()
+			h.Set("Deprecation", "@"+strconv.FormatInt(legacyDeprecatedAt.Unix(), 10))
+			h.Set("Sunset", LegacySunset.UTC().Format(http.TimeFormat))
+			h.Add("Link", "<"+legacyPrefix+r.URL.RequestURI()+`>; rel="successor-version"`)
+			metrics.DeprecatedRequests.WithLabelValues(routeTemplate(r)).Inc()
+			logger.FromContext(r.Context()).Debug("deprecated route is called", "route", routeTemplate(r), "successor", legacyPrefix+r.URL.Path)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
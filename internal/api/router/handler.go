@@ -3,7 +3,13 @@ package router
 import (
 	"bookApp/internal/api/router/httpErrors"
 	"bookApp/internal/domain/entities"
+	"bookApp/internal/domain/repos"
+	"bookApp/pkg/export"
+	"bookApp/pkg/logger"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -42,7 +48,7 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetBooks(w http.ResponseWriter, r *http.Request) {
-	books, err := BookRepo.FindAll()
+	books, err := BookRepo.FindAll(r.Context())
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -51,7 +57,7 @@ func GetBooks(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetBooksInludingDeleted(w http.ResponseWriter, r *http.Request) {
-	books, err := BookRepo.FindAllIncludingDeleted()
+	books, err := BookRepo.FindAllIncludingDeleted(r.Context())
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -60,7 +66,7 @@ func GetBooksInludingDeleted(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetBooksInStock(w http.ResponseWriter, r *http.Request) {
-	books, err := BookRepo.FindAllInStock()
+	books, err := BookRepo.FindAllInStock(r.Context())
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -70,19 +76,28 @@ func GetBooksInStock(w http.ResponseWriter, r *http.Request) {
 
 func GetBooksUnderPrice(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	price, _ := strconv.ParseFloat(vars["priceunder"], 32)
-	books, err := BookRepo.FindAllBooksUnderPrice(float32(price))
+	price, err := strconv.ParseFloat(vars["priceunder"], 32)
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadQueryParams.Error(), "priceunder must be a number"))
+		return
+	}
+	books, err := BookRepo.FindAllBooksUnderPrice(r.Context(), float32(price))
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
 	}
+	// v1 has always answered an empty result with an error, it is not found rather than a failure of the server
+	if len(books) == 0 {
+		respondWithError(w, httpErrors.NewApiError(http.StatusNotFound, httpErrors.NotFound.Error(), fmt.Sprintf("There is no books in the stock under %.2f", price)))
+		return
+	}
 	respondWithJson(w, http.StatusOK, books)
 }
 
 func GetBookByBookID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
-	book, err := BookRepo.FindByBookID(id)
+	book, err := BookRepo.FindByBookID(r.Context(), id)
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -94,7 +109,7 @@ func GetBookByBookID(w http.ResponseWriter, r *http.Request) {
 func GetBookByISBN(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	isbn := vars["isbn"]
-	book, err := BookRepo.FindByBookISBN(isbn)
+	book, err := BookRepo.FindByBookISBN(r.Context(), isbn)
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -105,7 +120,7 @@ func GetBookByISBN(w http.ResponseWriter, r *http.Request) {
 func GetBookByName(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
-	books, err := BookRepo.FindByBookName(name)
+	books, err := BookRepo.FindByBookName(r.Context(), name)
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -116,11 +131,12 @@ func GetBookByName(w http.ResponseWriter, r *http.Request) {
 func DeleteBookById(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
-	err := BookRepo.DeleteByBookID(id)
+	err := BookRepo.DeleteByBookID(r.Context(), id)
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
 	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(http.StatusText(http.StatusOK)))
 }
@@ -134,12 +150,22 @@ func BuyBookById(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
 	}
-	err = BookRepo.BuyByBookID(id, quantiy)
+	if quantiy < 1 {
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadRequest.Error(), repos.ErrInvalidQuantity.Error()))
+		return
+	}
+	err = BookRepo.BuyByBookID(r.Context(), id, quantiy)
+	var stockErr *repos.StockError
+	if errors.As(err, &stockErr) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusConflict, httpErrors.OutOfStock.Error(), err))
+		return
+	}
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
 	}
 
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(http.StatusText(http.StatusOK)))
 }
@@ -151,7 +177,11 @@ func AddBookToDatabase(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
 	}
-	err = BookRepo.AddBook(newBook)
+	err = BookRepo.AddBook(r.Context(), newBook)
+	if errors.Is(err, repos.ErrStockIDExists) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusConflict, httpErrors.ExistsStockIDError.Error(), err.Error()))
+		return
+	}
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -161,7 +191,7 @@ func AddBookToDatabase(w http.ResponseWriter, r *http.Request) {
 
 func GetAuthorsWithBookInfo(w http.ResponseWriter, r *http.Request) {
 
-	authors, err := AuthorRepo.FindAuthorsWithBookInfo()
+	authors, err := AuthorRepo.FindAuthorsWithBookInfo(r.Context())
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -172,7 +202,7 @@ func GetAuthorsWithBookInfo(w http.ResponseWriter, r *http.Request) {
 
 func GetAuthorsWithoutBookInfo(w http.ResponseWriter, r *http.Request) {
 
-	authors, err := AuthorRepo.FindAuthorsWithoutBookInfo()
+	authors, err := AuthorRepo.FindAuthorsWithoutBookInfo(r.Context())
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -184,7 +214,7 @@ func GetAuthorsWithoutBookInfo(w http.ResponseWriter, r *http.Request) {
 func GetAuthorByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
-	author, err := AuthorRepo.FindByAuthorID(id)
+	author, err := AuthorRepo.FindByAuthorID(r.Context(), id)
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -195,7 +225,7 @@ func GetAuthorByID(w http.ResponseWriter, r *http.Request) {
 func GetAuthorByName(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
-	authors, err := AuthorRepo.FindByAuthorName(name)
+	authors, err := AuthorRepo.FindByAuthorName(r.Context(), name)
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
@@ -206,10 +236,107 @@ func GetAuthorByName(w http.ResponseWriter, r *http.Request) {
 func GetBooksOfAuthorByName(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
-	authors, err := AuthorRepo.FindBooksOfAuthorByName(name)
+	authors, err := AuthorRepo.FindBooksOfAuthorByName(r.Context(), name)
 	if err != nil {
 		respondWithError(w, httpErrors.ParseErrors(err))
 		return
 	}
 	respondWithJson(w, http.StatusOK, authors)
 }
+
+// GetAuditTrail: returns the audit entries of a book or author, oldest first, the entity and the id are required
+func GetAuditTrail(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	entity := vars["entity"]
+	if entity != entities.AuditEntityBook && entity != entities.AuditEntityAuthor {
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadQueryParams.Error(), fmt.Sprintf("entity must be %s or %s", entities.AuditEntityBook, entities.AuditEntityAuthor)))
+		return
+	}
+	if vars["id"] == "" {
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadQueryParams.Error(), "id is required"))
+		return
+	}
+	entries, err := AuditRepo.FindByEntity(r.Context(), entity, vars["id"])
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, entries)
+}
+
+// exportResponseWriter: keeps track of whether the export has started to be written to the client
+type exportResponseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (e *exportResponseWriter) Write(b []byte) (int, error) {
+	e.written = true
+	return e.ResponseWriter.Write(b)
+}
+
+// parseBoolQuery: parses an optional boolean query param, false is returned if it is not given
+func parseBoolQuery(r *http.Request, key string) (bool, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadQueryParams.Error(), err)
+	}
+	return parsed, nil
+}
+
+// startExport: parses the export query params (format, deleted) and sets the response headers of the export file
+func startExport(w http.ResponseWriter, r *http.Request, name string) (*exportResponseWriter, export.Format, bool, error) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return nil, "", false, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadQueryParams.Error(), err)
+	}
+	includeDeleted, err := parseBoolQuery(r, "deleted")
+	if err != nil {
+		return nil, "", false, err
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format.Extension()))
+	return &exportResponseWriter{ResponseWriter: w}, format, includeDeleted, nil
+}
+
+// finishExport: if the export fails before anything is sent to the client an error response is created
+func finishExport(w http.ResponseWriter, r *http.Request, ew *exportResponseWriter, err error) {
+	if err == nil {
+		return
+	}
+	if !ew.written {
+		w.Header().Del("Content-Disposition")
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	logger.FromContext(r.Context()).Warn("export is interrupted", "error", err)
+}
+
+func ExportBooks(w http.ResponseWriter, r *http.Request) {
+	ew, format, includeDeleted, err := startExport(w, r, "books")
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	err = BookRepo.WriteBooks(r.Context(), ew, format, includeDeleted)
+	finishExport(w, r, ew, err)
+}
+
+func ExportAuthors(w http.ResponseWriter, r *http.Request) {
+	ew, format, includeDeleted, err := startExport(w, r, "authors")
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	if format == export.ONIX {
+		w.Header().Del("Content-Disposition")
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadQueryParams.Error(), "authors cannot be exported as onix"))
+		return
+	}
+	err = AuthorRepo.WriteAuthors(r.Context(), ew, format, includeDeleted)
+	finishExport(w, r, ew, err)
+}
@@ -0,0 +1,136 @@
+package router
+
+import (
+	"bookApp/internal/domain/migrations"
+	"bookApp/pkg/db"
+	"bookApp/pkg/logger"
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Readiness: tracks whether the app can serve traffic
+type Readiness struct {
+	monitor      *db.Monitor
+	migrator     *migrations.Migrator
+	config       db.HealthConfig
+	importDone   int32
+	shuttingDown int32
+
+	// the pending migrations are counted at most once per health interval, not on every probe
+	mu        sync.Mutex
+	checkedAt time.Time
+	pending   int
+}
+
+// CheckResult: state of a single dependency of the app
+type CheckResult struct {
+	Status  string      `json:"status"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// HealthReport: overall state of the app with the state of each dependency
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+const (
+	statusUp   = "up"
+	statusDown = "down"
+)
+
+// NewReadiness: the pending migrations are counted with the timeout of the health config and cached for its interval
+func NewReadiness(monitor *db.Monitor, migrator *migrations.Migrator, config db.HealthConfig) *Readiness {
+	return &Readiness{monitor: monitor, migrator: migrator, config: config}
+}
+
+// MarkImportDone: marks the initial import as finished
+func (r *Readiness) MarkImportDone() {
+	atomic.StoreInt32(&r.importDone, 1)
+}
+
+// MarkShuttingDown: makes the readiness fail so that the traffic is drained before the server is shut down
+func (r *Readiness) MarkShuttingDown() {
+	atomic.StoreInt32(&r.shuttingDown, 1)
+}
+
+// Check: returns the state of every dependency, the app is ready only if all of them are up
+func (r *Readiness) Check(ctx context.Context) HealthReport {
+	report := HealthReport{Status: statusUp, Checks: map[string]CheckResult{}}
+	set := func(name string, up bool, details interface{}) {
+		result := CheckResult{Status: statusUp, Details: details}
+		if !up {
+			result.Status = statusDown
+			report.Status = statusDown
+		}
+		report.Checks[name] = result
+	}
+
+	health := r.monitor.Health()
+	set("database", health.Healthy, struct {
+		db.Health
+		Pool db.PoolStats `json:"pool"`
+	}{health, r.monitor.Stats()})
+
+	pending, err := r.pendingMigrations(ctx)
+	if err != nil {
+		set("migrations", false, map[string]string{"error": err.Error()})
+	} else {
+		set("migrations", pending == 0, map[string]int{"pending": pending})
+	}
+
+	set("import", atomic.LoadInt32(&r.importDone) == 1, nil)
+	set("shutdown", atomic.LoadInt32(&r.shuttingDown) == 0, nil)
+	return report
+}
+
+// pendingMigrations: the number of pending migrations, counted again once the health interval has passed since the last count
+// the count only reads the schema table and it is cancelled with the probe or after the health timeout, a failed count is not cached
+func (r *Readiness) pendingMigrations(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.checkedAt.IsZero() && time.Since(r.checkedAt) < r.config.Interval {
+		return r.pending, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+	pending, err := r.migrator.CountPending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	r.pending, r.checkedAt = pending, time.Now()
+	return pending, nil
+}
+
+// HealthzHandler: reports that the process is alive
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJson(w, http.StatusOK, HealthReport{Status: statusUp})
+}
+
+// Public: the report without the details of the checks, the errors of the database and the pool stats are not shown to anonymous callers
+func (h HealthReport) Public() HealthReport {
+	public := HealthReport{Status: h.Status, Checks: make(map[string]CheckResult, len(h.Checks))}
+	for name, check := range h.Checks {
+		public.Checks[name] = CheckResult{Status: check.Status}
+	}
+	return public
+}
+
+// ReadyzHandler: reports whether the app is ready to serve traffic with the state of each dependency
+// the details of the checks that are down are logged, the response only has their states
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	report := Ready.Check(r.Context())
+	code := http.StatusOK
+	if report.Status != statusUp {
+		code = http.StatusServiceUnavailable
+		for name, check := range report.Checks {
+			if check.Status == statusDown {
+				logger.FromContext(r.Context()).Warn("readiness check is down", "check", name, "details", check.Details)
+			}
+		}
+	}
+	respondWithJson(w, code, report.Public())
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"bookApp/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestReadyzHidesDetails: the readiness probe is public, it only answers the states of the checks
+// and the details of the checks that are down are logged instead
+func TestReadyzHidesDetails(t *testing.T) {
+	mr, _ := newTestRouter(t)
+	var logs bytes.Buffer
+	l, _ := logger.New(&logs, logger.LevelWarn, logger.FormatText)
+	logger.SetDefault(l)
+
+	readyz := func() (int, string, HealthReport) {
+		rec := httptest.NewRecorder()
+		mr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var body struct {
+			Data HealthReport `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, rec.Body.String(), body.Data
+	}
+
+	code, raw, report := readyz()
+	if code != http.StatusOK || report.Status != statusUp {
+		t.Fatalf("status %d: %s", code, raw)
+	}
+	if strings.Contains(raw, "details") || strings.Contains(raw, "pool") {
+		t.Errorf("the details of the checks are public: %s", raw)
+	}
+
+	Ready.MarkShuttingDown()
+	code, raw, report = readyz()
+	if code != http.StatusServiceUnavailable || report.Status != statusDown || report.Checks["shutdown"].Status != statusDown {
+		t.Fatalf("status %d while shutting down: %s", code, raw)
+	}
+	if report.Checks["database"].Status != statusUp || strings.Contains(raw, "details") {
+		t.Errorf("the checks are not only their states: %s", raw)
+	}
+	if !strings.Contains(logs.String(), "readiness check is down") || !strings.Contains(logs.String(), "shutdown") {
+		t.Errorf("the check that is down is not logged: %s", logs.String())
+	}
+}
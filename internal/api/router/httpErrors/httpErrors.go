@@ -1,8 +1,10 @@
 package httpErrors
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 )
@@ -27,6 +29,14 @@ var (
 	InternalServerError = errors.New("Internal Server Error")
 	MissingFields       = errors.New("Missing fields")
 	ExistsObjectIDError = errors.New("Object with given id already exists")
+	ExistsStockIDError  = errors.New("Object with given stock id already exists")
+	QueryTimeout        = errors.New("Query timeout")
+	QueryCanceled       = errors.New("Query canceled")
+	Unauthorized        = errors.New("Unauthorized")
+	Forbidden           = errors.New("Forbidden")
+	TooManyRequests     = errors.New("Too Many Requests")
+	OutOfStock          = errors.New("Not enough stock")
+	NotDeleted          = errors.New("Book is not deleted")
 )
 
 func (a ApiError) Status() int {
@@ -49,6 +59,10 @@ func NewApiError(code int, err string, causes interface{}) ApiError {
 // ParseErrors : parses error to a specific structure (ApiError)
 func ParseErrors(err error) ApiErr {
 	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewApiError(http.StatusGatewayTimeout, QueryTimeout.Error(), err)
+	case errors.Is(err, context.Canceled):
+		return NewApiError(http.StatusServiceUnavailable, QueryCanceled.Error(), err)
 	case strings.Contains(err.Error(), "json: unsupported"):
 		return NewApiError(http.StatusBadRequest, CannotMarshal.Error(), err)
 	case strings.Contains(err.Error(), "not found"):
@@ -57,8 +71,14 @@ func ParseErrors(err error) ApiErr {
 		return NewApiError(http.StatusBadRequest, MissingFields.Error(), err)
 	case strings.Contains(err.Error(), "SQLSTATE"):
 		return parseSqlErrors(err)
+	case strings.Contains(err.Error(), "UNIQUE constraint failed"):
+		// the unique violation of sqlite
+		return NewApiError(http.StatusConflict, ExistsObjectIDError.Error(), err)
 	case strings.Contains(err.Error(), "Unmarshal"):
 		return NewApiError(http.StatusBadRequest, BadRequest.Error(), err)
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), strings.Contains(err.Error(), "invalid character"), strings.Contains(err.Error(), "json: unknown field"):
+		// an empty, truncated or malformed json body, or one with a field the request does not have
+		return NewApiError(http.StatusBadRequest, BadRequest.Error(), err)
 	default:
 		if apiErr, ok := err.(ApiErr); ok {
 			return apiErr
@@ -70,7 +90,8 @@ func ParseErrors(err error) ApiErr {
 // parseSqlErrors : if given error is an sql error, parse it explicitly
 func parseSqlErrors(err error) ApiErr {
 	if strings.Contains(err.Error(), "23505") {
-		return NewApiError(http.StatusBadRequest, ExistsObjectIDError.Error(), err)
+		// unique_violation, e.g. a row inserted concurrently with the same key
+		return NewApiError(http.StatusConflict, ExistsObjectIDError.Error(), err)
 	}
 	return NewApiError(http.StatusBadRequest, BadRequest.Error(), err)
 }
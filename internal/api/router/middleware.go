@@ -0,0 +1,142 @@
+package router
+
+import (
+	"bookApp/internal/metrics"
+	"bookApp/internal/tracing"
+	"bookApp/pkg/logger"
+	"bookApp/pkg/requestid"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestID: propagates the X-Request-ID header of the request or generates one,
+// the id is sent back in the response and added to the logger of the request context
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+
+		ctx := requestid.NewContext(r.Context(), id)
+		ctx = logger.NewContext(ctx, logger.FromContext(ctx).With("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AccessLog: logs every request with its route, status, size and latency when it is finished
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		l := logger.FromContext(r.Context())
+		fields := []interface{}{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", routeTemplate(r),
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"remote", r.RemoteAddr,
+		}
+		if rec.status >= http.StatusInternalServerError {
+			l.Warn("request", fields...)
+			return
+		}
+		l.Info("request", fields...)
+	})
+}
+
+// Instrument: counts the requests and observes their latency by the path template of the matched route
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		metrics.ObserveHTTP(routeTemplate(r), r.Method, rec.status, time.Since(start))
+	})
+}
+
+// routeTemplate: returns the path template of the route matching the request, "unmatched" if there is none
+// templates are used instead of paths so that the ids in the paths do not create new log values and metric series
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+// Trace: starts the server span of the request as a child of the W3C trace context in the request headers,
+// the trace id is added to the logger of the request context
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := routeTemplate(r)
+		ctx, span := tracing.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("bookApp", route, r)...),
+			trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", r)...),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			ctx = logger.NewContext(ctx, logger.FromContext(ctx).With("trace_id", sc.TraceID().String()))
+		}
+		if id := requestid.FromContext(ctx); id != "" {
+			span.SetAttributes(attribute.String("http.request_id", id))
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(rec.status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(rec.status))
+	})
+}
+
+// withMiddlewares: wraps handlers that are not routes of the router (not found, method not allowed) with the middlewares of the routes
+func withMiddlewares(h http.Handler) http.Handler {
+	return RequestID(Trace(AccessLog(Instrument(SecureHeaders(CrossOrigin(h))))))
+}
+
+// statusRecorder: keeps the status code and the size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+// Flush: lets streamed responses (exports) be flushed through the recorder
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,215 @@
+package router
+
+import (
+	"bookApp/internal/api/openapi"
+	"bookApp/internal/api/router/httpErrors"
+	"bookApp/internal/auth"
+	"bookApp/internal/ratelimit"
+	"bookApp/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// docsPolicy: the content security policy of the docs page, it loads its own scripts and styles and the document only
+const docsPolicy = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; img-src 'self' data:; frame-ancestors 'none'"
+
+// spec: the document of the routes registered by Handle, it is served at /openapi.json
+var spec []byte
+
+// pathParam: a variable of a path template, e.g. {id}
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// OpenAPI: returns the OpenAPI document of the routes registered on the router
+// the security, the permission and the rate limit group of the operations are filled from the declarations of the routes
+// and the deprecated aliases are described as their v1 routes, an error is returned if a route is not documented
+// or a documented operation is not registered, the returned document is complete otherwise
+func OpenAPI(mr *mux.Router) (*openapi.Document, error) {
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	aliases := map[string]openapi.PathItem{}
+	registered := map[string]bool{}
+	problems := []string{}
+	err = mr.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		// the path prefixes of the subrouters have no handler, the preflight route answers OPTIONS on every path
+		if route.GetHandler() == nil || route.GetName() == "preflight" {
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		documented := path
+		if legacyRoutes[route] {
+			documented = legacyPrefix + path
+		}
+		item, ok := doc.Paths[documented]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("route %s is not documented", path))
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		if len(methods) == 0 {
+			// the route matches every method, e.g. the home route, at least one of them is documented
+			for method := range item {
+				methods = append(methods, strings.ToUpper(method))
+			}
+		}
+		for _, method := range methods {
+			op := doc.Operation(method, documented)
+			if op == nil {
+				problems = append(problems, fmt.Sprintf("route %s %s is not documented", method, path))
+				continue
+			}
+			registered[method+" "+documented] = true
+			if err := checkParameters(doc, route, op); err != nil {
+				problems = append(problems, fmt.Sprintf("route %s %s: %v", method, path, err))
+			}
+			describeDeclarations(route, method, op)
+			if legacyRoutes[route] {
+				if aliases[path] == nil {
+					aliases[path] = openapi.PathItem{}
+				}
+				aliases[path][strings.ToLower(method)] = legacyOperation(op, method, documented)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for path, item := range doc.Paths {
+		for method := range item {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				problems = append(problems, fmt.Sprintf("documented operation %s %s is not registered", strings.ToUpper(method), path))
+			}
+		}
+	}
+	for path, item := range aliases {
+		doc.Paths[path] = item
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return doc, fmt.Errorf("the openapi document does not match the routes:\n%s", strings.Join(problems, "\n"))
+	}
+	return doc, nil
+}
+
+// checkParameters: the variables of the path and the queries of the route must be parameters of the operation
+// and the referenced parameters and responses must exist
+func checkParameters(doc *openapi.Document, route *mux.Route, op *openapi.Operation) error {
+	for _, r := range op.Responses {
+		if _, err := doc.Response(r); err != nil {
+			return err
+		}
+	}
+	params := map[string]bool{}
+	for _, p := range op.Parameters {
+		resolved, err := doc.Parameter(p)
+		if err != nil {
+			return err
+		}
+		params[resolved.In+":"+resolved.Name] = true
+	}
+	path, _ := route.GetPathTemplate()
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		if !params["path:"+match[1]] {
+			return fmt.Errorf("path parameter %s is not documented", match[1])
+		}
+	}
+	queries, _ := route.GetQueriesTemplates()
+	for _, query := range queries {
+		name := strings.SplitN(query, "=", 2)[0]
+		if !params["query:"+name] {
+			return fmt.Errorf("query parameter %s is not documented", name)
+		}
+	}
+	return nil
+}
+
+// describeDeclarations: fills the permission and the rate limit group of the route into the operation
+// with the security requirements and the responses they cause, the routes of the queries of an operation
+// may have different groups, e.g. the name searches, the groups are listed then
+func describeDeclarations(route *mux.Route, method string, op *openapi.Operation) {
+	group, ok := routeGroups[route]
+	if !ok {
+		group = methodGroup(method)
+	}
+	if group != ratelimit.GroupNone {
+		op.Responses["429"] = &openapi.Response{Ref: "#/components/responses/TooManyRequests"}
+		if op.RateLimitGroup == "" {
+			op.RateLimitGroup = string(group)
+		} else if !strings.Contains(","+op.RateLimitGroup+",", ","+string(group)+",") {
+			op.RateLimitGroup += "," + string(group)
+		}
+	}
+
+	if op.Permission != "" {
+		return
+	}
+	permission := routePermissions[route]
+	op.Permission = string(permission)
+	if permission != auth.PermPublic {
+		op.Security = []map[string][]string{{"apiKey": {}}, {"bearer": {}}}
+		op.Responses["401"] = &openapi.Response{Ref: "#/components/responses/Unauthorized"}
+		op.Responses["403"] = &openapi.Response{Ref: "#/components/responses/Forbidden"}
+		roles := []string{}
+		for _, r := range auth.RolesWith(permission) {
+			roles = append(roles, string(r))
+		}
+		op.Description = strings.TrimSpace(op.Description + "\n\nRequires the `" + string(permission) + "` permission, the roles having it: " + strings.Join(roles, ", ") + ".")
+	}
+}
+
+// legacyOperation: the operation of a deprecated alias, a copy of the v1 operation
+func legacyOperation(op *openapi.Operation, method, successor string) *openapi.Operation {
+	alias := *op
+	alias.OperationID = "legacy" + strings.ToUpper(op.OperationID[:1]) + op.OperationID[1:]
+	alias.Deprecated = true
+	alias.Description = strings.TrimSpace(fmt.Sprintf("Deprecated alias of `%s %s`, it is removed on %s.\n\n%s",
+		method, successor, LegacySunset.UTC().Format("2006-01-02"), op.Description))
+	return &alias
+}
+
+// buildSpec: builds the document served at /openapi.json, a mismatch of the document and the routes is logged
+// the openapi command and TestOpenAPI fail on it, so it is caught before a release
+func buildSpec(mr *mux.Router) {
+	doc, err := OpenAPI(mr)
+	if err != nil {
+		logger.Warn("openapi document is incomplete", "error", err)
+	}
+	if doc == nil {
+		spec = nil
+		return
+	}
+	spec, err = json.Marshal(doc)
+	if err != nil {
+		logger.Warn("openapi document cannot be marshalled", "error", err)
+	}
+}
+
+// OpenAPIHandler: serves the OpenAPI document of the api
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if spec == nil {
+		respondWithError(w, httpErrors.NewInternalServerError("the openapi document is not available"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// DocsHandler: serves the docs page, its policy allows its own scripts and styles which the api policy does not
+func DocsHandler() http.Handler {
+	files := http.StripPrefix("/docs/", http.FileServer(http.FS(openapi.UI())))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", docsPolicy)
+		files.ServeHTTP(w, r)
+	})
+}
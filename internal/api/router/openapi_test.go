@@ -0,0 +1,22 @@
+package router_test
+
+import (
+	"bookApp/internal/api/router"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// TestOpenAPI: every registered route is documented and every documented operation is registered
+func TestOpenAPI(t *testing.T) {
+	mr := mux.NewRouter()
+	router.Handle(mr)
+	doc, err := router.OpenAPI(mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op := doc.Operation(http.MethodPost, "/v2/books/{id}/orders"); op == nil {
+		t.Fatal("the operation POST /v2/books/{id}/orders is not in the document")
+	}
+}
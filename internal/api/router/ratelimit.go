@@ -0,0 +1,96 @@
+package router
+
+import (
+	"bookApp/internal/api/router/httpErrors"
+	"bookApp/internal/auth"
+	"bookApp/internal/metrics"
+	"bookApp/internal/ratelimit"
+	"bookApp/pkg/logger"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Limiter: limits the requests of every caller in the route groups, nil disables rate limiting
+// TrustedProxies: the proxies whose X-Forwarded-For header identifies the client of an anonymous request
+var (
+	Limiter        *ratelimit.Limiter
+	TrustedProxies []*net.IPNet
+)
+
+// failedAuthGroup: the group charged for the failed authentications whatever the group of the route is,
+// the probes and the preflights are not limited but must not let credentials be guessed without a limit
+const failedAuthGroup = ratelimit.GroupWrite
+
+// routeGroups: the groups of the routes declared with limitAs, the other routes are grouped by their methods
+var routeGroups = map[*mux.Route]ratelimit.Group{}
+
+// limitAs: declares the rate limit group of the route
+func limitAs(group ratelimit.Group, route *mux.Route) *mux.Route {
+	routeGroups[route] = group
+	return route
+}
+
+// routeGroup: returns the group of the matched route, reads and writes are told apart by the method
+func routeGroup(r *http.Request) ratelimit.Group {
+	if group, ok := routeGroups[mux.CurrentRoute(r)]; ok {
+		return group
+	}
+	return methodGroup(r.Method)
+}
+
+// methodGroup: the group of the routes without a declared group
+func methodGroup(method string) ratelimit.Group {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ratelimit.GroupRead
+	}
+	return ratelimit.GroupWrite
+}
+
+// caller: authenticated callers are limited by their api key or user, anonymous ones by their ip address
+func caller(r *http.Request) string {
+	if principal := auth.FromContext(r.Context()); principal != nil {
+		return principal.Method + ":" + principal.Subject
+	}
+	return clientCaller(r)
+}
+
+// clientCaller: the bucket of the ip address of the client, the anonymous requests and the failed authentications share it
+func clientCaller(r *http.Request) string {
+	return "ip:" + ratelimit.ClientIP(r, TrustedProxies)
+}
+
+// RateLimit: rejects the requests of a caller exceeding the budget of the route group with 429
+// if the store fails the request is let through, an unavailable store must not take the api down
+func RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if throttle(w, r, routeGroup(r), caller(r)) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// throttle: takes a token of the caller in the group and reports whether the request is rejected
+// the rejected requests are answered with 429, the RateLimit-* headers are set on every limited request
+func throttle(w http.ResponseWriter, r *http.Request, group ratelimit.Group, caller string) bool {
+	if Limiter == nil || Limiter.Limit(group).Unlimited() {
+		return false
+	}
+	result, err := Limiter.Take(r.Context(), group, caller)
+	if err != nil {
+		logger.FromContext(r.Context()).Warn("rate limit store failed", "group", group, "error", err)
+		return false
+	}
+	result.Headers(w.Header(), Limiter.Limit(group))
+	if !result.Allowed {
+		metrics.RateLimitRejections.WithLabelValues(string(group)).Inc()
+		respondWithError(w, httpErrors.NewApiError(http.StatusTooManyRequests, httpErrors.TooManyRequests.Error(),
+			fmt.Sprintf("rate limit of the %s routes is exceeded, retry after %s", group, w.Header().Get("Retry-After")+"s")))
+		return true
+	}
+	return false
+}
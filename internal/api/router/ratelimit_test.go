@@ -0,0 +1,67 @@
+package router
+
+import (
+	"bookApp/internal/auth"
+	"bookApp/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestFailedAuthenticationIsThrottled: the invalid credentials are answered with 401 until the budget of the client ip
+// is spent, the later attempts get 429, a valid token of the same client has its own bucket
+func TestFailedAuthenticationIsThrottled(t *testing.T) {
+	mr, tokens := newTestRouter(t)
+	Limiter = ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Config{Write: ratelimit.Limit{Rate: 0.01, Burst: 2}})
+	defer func() { Limiter = nil }()
+
+	order := func(mr *mux.Router, credentials string) int {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/books/order?id=1&quantity=1", nil)
+		req.Header.Set("Authorization", credentials)
+		rec := httptest.NewRecorder()
+		mr.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		if got := order(mr, "Bearer invalid"); got != want {
+			t.Errorf("attempt %d: status %d, want %d", i+1, got, want)
+		}
+	}
+
+	token, err := tokens.Issue("clerk", []auth.Role{auth.RoleClerk}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := order(mr, "Bearer "+token); got != http.StatusOK {
+		t.Errorf("valid token: status %d, want %d", got, http.StatusOK)
+	}
+}
+
+// TestFailedAuthenticationOnProbesIsThrottled: the probes are never limited, but the invalid credentials sent to them
+// are charged to the write group like on any other route
+func TestFailedAuthenticationOnProbesIsThrottled(t *testing.T) {
+	mr, _ := newTestRouter(t)
+	Limiter = ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Config{Write: ratelimit.Limit{Rate: 0.01, Burst: 2}})
+	defer func() { Limiter = nil }()
+
+	healthz := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		mr.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if got := healthz("bk_invalid"); got != want {
+			t.Errorf("attempt %d: status %d, want %d", i+1, got, want)
+		}
+	}
+	if got := healthz(""); got != http.StatusOK {
+		t.Errorf("anonymous probe: status %d, want %d", got, http.StatusOK)
+	}
+}
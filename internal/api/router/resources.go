@@ -0,0 +1,272 @@
+package router
+
+import (
+	"bookApp/internal/api/dto"
+	"bookApp/internal/api/router/httpErrors"
+	"bookApp/internal/auth"
+	"bookApp/internal/domain/entities"
+	"bookApp/internal/domain/repos"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Resource handlers: below are the handlers of the resource routes of v2, the books and authors are mapped to the dto package
+// in the view of the caller, so the responses keep their shape when the entities change and the restricted fields are omitted
+
+// viewOf: the view of the caller of the request
+func viewOf(r *http.Request) dto.View {
+	return dto.ViewOf(auth.FromContext(r.Context()))
+}
+
+// decodeBody: decodes the json body of the request, a field that is not part of the body is an error
+func decodeBody(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// ListBooks: returns the books, ?inStock=true lists only those in stock and ?deleted=true includes the soft deleted ones
+// the soft deleted books are not public, they are listed to the callers with the export permission only
+func ListBooks(w http.ResponseWriter, r *http.Request) {
+	inStock, err := parseBoolQuery(r, "inStock")
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	deleted, err := parseBoolQuery(r, "deleted")
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+
+	var books []entities.Book
+	switch {
+	case inStock && deleted:
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadQueryParams.Error(), "inStock and deleted cannot be combined"))
+		return
+	case inStock:
+		books, err = BookRepo.FindAllInStock(r.Context())
+	case deleted:
+		if !authorized(w, r, auth.PermExportCatalog) {
+			return
+		}
+		books, err = BookRepo.FindAllIncludingDeleted(r.Context())
+	default:
+		books, err = BookRepo.FindAll(r.Context())
+	}
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewBooks(books, viewOf(r)))
+}
+
+// ListBooksUnderPrice: returns the books in stock cheaper than maxPrice, the list is empty if there is none
+// NaN and the infinities are parsed as numbers but are no prices
+func ListBooksUnderPrice(w http.ResponseWriter, r *http.Request) {
+	price, err := strconv.ParseFloat(mux.Vars(r)["maxPrice"], 32)
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadQueryParams.Error(), "maxPrice must be a number"))
+		return
+	}
+	books, err := BookRepo.FindAllBooksUnderPrice(r.Context(), float32(price))
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewBooks(books, viewOf(r)))
+}
+
+// ListBooksByName: returns the books whose name contains the text
+func ListBooksByName(w http.ResponseWriter, r *http.Request) {
+	books, err := BookRepo.FindByBookName(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewBooks(books, viewOf(r)))
+}
+
+// FindBookByISBN: returns the book of the ISBN
+func FindBookByISBN(w http.ResponseWriter, r *http.Request) {
+	book, err := BookRepo.FindByBookISBN(r.Context(), mux.Vars(r)["isbn"])
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewBook(book, viewOf(r)))
+}
+
+// GetBook: returns the book of the ID
+func GetBook(w http.ResponseWriter, r *http.Request) {
+	book, err := BookRepo.FindByBookID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewBook(book, viewOf(r)))
+}
+
+// CreateBook: adds the book (and its author if it is new) and responds with 201 and the location of the book
+// a book with the ID or the stock ID of an existing one is a conflict
+func CreateBook(w http.ResponseWriter, r *http.Request) {
+	var request dto.BookRequest
+	if err := decodeBody(r, &request); err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadRequest.Error(), err))
+		return
+	}
+	newBook := request.Entity()
+	err := BookRepo.CreateBook(r.Context(), newBook)
+	if errors.Is(err, repos.ErrBookExists) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusConflict, httpErrors.ExistsObjectIDError.Error(), err))
+		return
+	}
+	if errors.Is(err, repos.ErrStockIDExists) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusConflict, httpErrors.ExistsStockIDError.Error(), err.Error()))
+		return
+	}
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	// the stored book is returned, so the timestamps are set
+	book, err := BookRepo.FindByBookID(r.Context(), newBook.ID)
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	// the collection is posted to, so the path of the request is the path of the collection
+	w.Header().Set("Location", r.URL.Path+"/"+url.PathEscape(book.ID))
+	respondWithJson(w, http.StatusCreated, dto.NewBook(book, viewOf(r)))
+}
+
+// DeleteBook: soft deletes the book and responds with 204
+func DeleteBook(w http.ResponseWriter, r *http.Request) {
+	if err := BookRepo.DeleteByBookID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// OrderBook: orders the quantity of the body from the stock of the book, ordering more than the stock is a conflict
+func OrderBook(w http.ResponseWriter, r *http.Request) {
+	var order dto.OrderRequest
+	if err := decodeBody(r, &order); err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	if order.Quantity < 1 {
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadRequest.Error(), repos.ErrInvalidQuantity.Error()))
+		return
+	}
+	id := mux.Vars(r)["id"]
+	err := BookRepo.BuyByBookID(r.Context(), id, order.Quantity)
+	var stockErr *repos.StockError
+	if errors.As(err, &stockErr) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusConflict, httpErrors.OutOfStock.Error(), err))
+		return
+	}
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.Order{BookID: id, Quantity: order.Quantity})
+}
+
+// AdjustStock: sets the stock of the book to the stockNumber of the body and responds with the book
+func AdjustStock(w http.ResponseWriter, r *http.Request) {
+	var request dto.StockRequest
+	if err := decodeBody(r, &request); err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		respondWithError(w, httpErrors.NewApiError(http.StatusBadRequest, httpErrors.BadRequest.Error(), err))
+		return
+	}
+	book, err := BookRepo.SetStock(r.Context(), mux.Vars(r)["id"], *request.StockNumber)
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewBook(book, viewOf(r)))
+}
+
+// RestoreBook: restores the soft deleted book and responds with the book, restoring a book that is not deleted is a conflict
+func RestoreBook(w http.ResponseWriter, r *http.Request) {
+	book, err := BookRepo.RestoreByBookID(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, repos.ErrBookNotDeleted) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusConflict, httpErrors.NotDeleted.Error(), err))
+		return
+	}
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewBook(book, viewOf(r)))
+}
+
+// PurgeBook: permanently removes the soft deleted book and responds with 204, purging a book that is not deleted is a conflict
+func PurgeBook(w http.ResponseWriter, r *http.Request) {
+	err := BookRepo.PurgeByBookID(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, repos.ErrBookNotDeleted) {
+		respondWithError(w, httpErrors.NewApiError(http.StatusConflict, httpErrors.NotDeleted.Error(), err))
+		return
+	}
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAuthorBooks: returns the books of the author
+func ListAuthorBooks(w http.ResponseWriter, r *http.Request) {
+	author, err := AuthorRepo.FindByAuthorID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewBooks(author.Books, viewOf(r)))
+}
+
+// ListAuthors: returns the authors without their books
+func ListAuthors(w http.ResponseWriter, r *http.Request) {
+	authors, err := AuthorRepo.FindAuthorsWithoutBookInfo(r.Context())
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewAuthors(authors, viewOf(r)))
+}
+
+// ListAuthorsByName: returns the authors whose name contains the text
+func ListAuthorsByName(w http.ResponseWriter, r *http.Request) {
+	authors, err := AuthorRepo.FindByAuthorName(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewAuthors(authors, viewOf(r)))
+}
+
+// GetAuthor: returns the author of the ID with the books
+func GetAuthor(w http.ResponseWriter, r *http.Request) {
+	author, err := AuthorRepo.FindByAuthorID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, httpErrors.ParseErrors(err))
+		return
+	}
+	respondWithJson(w, http.StatusOK, dto.NewAuthor(author, viewOf(r)))
+}
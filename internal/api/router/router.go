@@ -1,7 +1,10 @@
 package router
 
 import (
+	"bookApp/internal/auth"
 	"bookApp/internal/domain/repos"
+	"bookApp/internal/metrics"
+	"bookApp/internal/ratelimit"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -10,31 +13,71 @@ import (
 var (
 	BookRepo   *repos.BookRepository
 	AuthorRepo *repos.AuthorRepository
+	AuditRepo  *repos.AuditRepository
+	Ready      *Readiness
 )
 
 func Handle(mr *mux.Router) {
 
+	// every request gets a request id, a trace span, an access log entry and metrics, including the ones that match no route
+	// the callers are identified by their credentials and every route declares the permission it requires with permit
+	// every caller has a budget of requests per route group, the groups of the routes other than plain reads and writes are declared with limitAs
+	// the responses have the security headers and the CORS headers of the allowed origins, the ones of the deprecated aliases are marked
+	mr.Use(RequestID, Trace, AccessLog, Instrument, SecureHeaders, DeprecatedAlias, CrossOrigin, Authenticate, RateLimit, Authorize)
+	mr.NotFoundHandler = withMiddlewares(http.NotFoundHandler())
+	mr.MethodNotAllowedHandler = withMiddlewares(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+
 	// home handler
-	mr.HandleFunc("/", HomeHandler)
-
-	// handlers regarding books
-	b := mr.PathPrefix("/books").Subrouter()
-	b.HandleFunc("/", GetBooks).Methods(http.MethodGet)
-	b.HandleFunc("/all", GetBooksInludingDeleted).Methods(http.MethodGet)
-	b.HandleFunc("/stock", GetBooksInStock).Methods(http.MethodGet)
-	b.HandleFunc("/price/{priceunder}", GetBooksUnderPrice).Methods(http.MethodGet)
-	b.HandleFunc("", GetBookByBookID).Methods(http.MethodGet).Queries("id", "{id}")
-	b.HandleFunc("", GetBookByISBN).Methods(http.MethodGet).Queries("isbn", "{isbn}")
-	b.HandleFunc("", GetBookByName).Methods(http.MethodGet).Queries("name", "{name}")
-	b.HandleFunc("/delete", DeleteBookById).Methods(http.MethodDelete).Queries("id", "{id}")
-	b.HandleFunc("/order", BuyBookById).Methods(http.MethodPatch).Queries("id", "{id}", "quantity", "{quantity}")
-	b.HandleFunc("/add", AddBookToDatabase).Methods(http.MethodPost)
-
-	// handlers regarding authors
-	a := mr.PathPrefix("/authors").Subrouter()
-	a.HandleFunc("/", GetAuthorsWithBookInfo).Methods(http.MethodGet)
-	a.HandleFunc("/*", GetAuthorsWithoutBookInfo).Methods(http.MethodGet)
-	a.HandleFunc("", GetAuthorByID).Methods(http.MethodGet).Queries("id", "{id}")
-	a.HandleFunc("", GetAuthorByName).Methods(http.MethodGet).Queries("name", "{name}")
-	a.HandleFunc("/books", GetBooksOfAuthorByName).Methods(http.MethodGet).Queries("name", "{name}")
+	permit(auth.PermPublic, mr.HandleFunc("/", HomeHandler))
+
+	// liveness and readiness probes
+	limitAs(ratelimit.GroupNone, permit(auth.PermPublic, mr.HandleFunc("/healthz", HealthzHandler).Methods(http.MethodGet)))
+	limitAs(ratelimit.GroupNone, permit(auth.PermPublic, mr.HandleFunc("/readyz", ReadyzHandler).Methods(http.MethodGet)))
+
+	// prometheus metrics
+	limitAs(ratelimit.GroupNone, permit(auth.PermPublic, mr.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)))
+
+	// the OpenAPI document of the routes and the docs page rendering it
+	permit(auth.PermPublic, mr.HandleFunc("/openapi.json", OpenAPIHandler).Methods(http.MethodGet))
+	permit(auth.PermPublic, mr.Handle("/docs", http.RedirectHandler("/docs/", http.StatusMovedPermanently)).Methods(http.MethodGet))
+	permit(auth.PermPublic, mr.PathPrefix("/docs/").Handler(DocsHandler()).Methods(http.MethodGet))
+
+	// the api is versioned, a breaking change of the routes or the responses goes into a new version
+	for _, v := range apiVersions {
+		v.routes(mr.PathPrefix(v.prefix).Subrouter())
+	}
+
+	// the v1 routes are also served at the root where they were before versioning, these aliases are deprecated
+	legacy := mr.NewRoute().Subrouter()
+	v1Routes(legacy)
+	legacy.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		legacyRoutes[route] = true
+		return nil
+	})
+
+	// preflight requests of the browsers, it matches every path so it is registered after the other routes
+	// the method is matched by a matcher func, a method matcher would answer the unknown paths with 405 instead of 404
+	limitAs(ratelimit.GroupNone, permit(auth.PermPublic, mr.PathPrefix("/").MatcherFunc(isOptions).HandlerFunc(Preflight).Name("preflight")))
+
+	// a route without a permission declaration would be rejected on every request, refuse to start instead
+	if err := checkPermissions(mr); err != nil {
+		panic(err)
+	}
+
+	// every route is described in the OpenAPI document, the document is built once the routes are registered
+	buildSpec(mr)
+}
+
+// apiVersion: a version of the api and the function registering its routes on the subrouter of its prefix
+type apiVersion struct {
+	prefix string
+	routes func(r *mux.Router)
+}
+
+// apiVersions: every served version of the api
+var apiVersions = []apiVersion{
+	{prefix: "/v1", routes: v1Routes},
+	{prefix: "/v2", routes: v2Routes},
 }
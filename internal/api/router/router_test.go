@@ -0,0 +1,189 @@
+package router
+
+import (
+	"bookApp/internal/auth"
+	"bookApp/internal/domain/entities"
+	"bookApp/internal/domain/migrations"
+	"bookApp/internal/domain/repos"
+	database "bookApp/pkg/db"
+	"bookApp/pkg/logger"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// seedFile: the sample data, relative to the directory of the package
+const seedFile = "../../../pkg/docs/data.csv"
+
+// testSecret: the secret of the tokens of the test callers
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newTestRouter: the router of the api on the in-memory database seeded with the sample data (pkg/docs/data.csv)
+// the callers are authenticated with the tokens of the returned issuer, the requests are not rate limited
+func newTestRouter(t *testing.T) (*mux.Router, *auth.TokenIssuer) {
+	t.Helper()
+	silent, _ := logger.New(io.Discard, logger.LevelError, logger.FormatText)
+	logger.SetDefault(silent)
+
+	db, err := database.NewMemoryDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		t.Fatal(err)
+	}
+	// the in-memory database is shared by the tests, every test starts with the sample data only
+	for _, model := range []interface{}{&entities.AuditEntry{}, &entities.Book{}, &entities.Author{}} {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(model).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	health := database.HealthConfig{Interval: time.Minute, Timeout: time.Second}
+	monitor, err := database.NewMonitor(db, health, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	BookRepo = repos.NewBookRepository(db)
+	AuthorRepo = repos.NewAuthorRepository(db)
+	AuditRepo = repos.NewAuditRepository(db)
+	Ready = NewReadiness(monitor, migrations.NewMigrator(db), health)
+	if err := BookRepo.SetupDatabase(context.Background(), seedFile, repos.DefaultCSVProfile()); err != nil {
+		t.Fatal(err)
+	}
+	if err := AuthorRepo.SetupDatabase(context.Background(), seedFile, repos.DefaultCSVProfile()); err != nil {
+		t.Fatal(err)
+	}
+	Ready.MarkImportDone()
+
+	tokens, err := auth.NewTokenIssuer(testSecret, "bookApp-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Auth = &auth.Authenticator{Tokens: tokens}
+	Limiter = nil
+
+	mr := mux.NewRouter()
+	Handle(mr)
+	return mr, tokens
+}
+
+// permissionRoles: the permission matrix the routes must enforce, a change of the matrix in the auth package must be made here as well
+var permissionRoles = map[auth.Permission][]auth.Role{
+	auth.PermPublic:        {auth.RoleReader, auth.RoleClerk, auth.RoleInventoryManager, auth.RoleAdmin},
+	auth.PermExportCatalog: {auth.RoleClerk, auth.RoleInventoryManager, auth.RoleAdmin},
+	auth.PermOrderBooks:    {auth.RoleClerk, auth.RoleInventoryManager, auth.RoleAdmin},
+	auth.PermWriteBooks:    {auth.RoleInventoryManager, auth.RoleAdmin},
+	auth.PermAdjustStock:   {auth.RoleInventoryManager, auth.RoleAdmin},
+	auth.PermDeleteBooks:   {auth.RoleAdmin},
+	auth.PermPurgeBooks:    {auth.RoleAdmin},
+	auth.PermReadAudit:     {auth.RoleInventoryManager, auth.RoleAdmin},
+}
+
+// templateVars: the variables of the path and query templates of the routes, e.g. {id} or {priceunder}
+var templateVars = regexp.MustCompile(`\{[^}]*\}`)
+
+// TestPermissionMatrix: calls every route anonymously and with a token of every role, the anonymous callers of a protected
+// route get 401, the roles without the permission get 403 and the others get past the authorization
+func TestPermissionMatrix(t *testing.T) {
+	mr, tokens := newTestRouter(t)
+	routes, err := RoutePermissions(mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, route := range routes {
+		if route.Name == "preflight" {
+			// matched by the OPTIONS method of any path, see TestPreflight
+			continue
+		}
+		allowed, ok := permissionRoles[route.Permission]
+		if !ok {
+			t.Errorf("route %s has the permission %s that is not in the matrix", route.Path, route.Permission)
+			continue
+		}
+		method := http.MethodGet
+		if len(route.Methods) > 0 {
+			method = route.Methods[0]
+		}
+		uri := templateVars.ReplaceAllString(route.Path, "1")
+		if len(route.Queries) > 0 {
+			uri += "?" + templateVars.ReplaceAllString(strings.Join(route.Queries, "&"), "1")
+		}
+
+		callers := append([]auth.Role{""}, auth.Roles...)
+		for _, role := range callers {
+			want := "authorized"
+			switch {
+			case role == "" && route.Permission != auth.PermPublic:
+				want = "401"
+			case role != "" && !hasRole(allowed, role):
+				want = "403"
+			}
+			caller := string(role)
+			if caller == "" {
+				caller = "anonymous"
+			}
+
+			t.Run(fmt.Sprintf("%s %s as %s", method, uri, caller), func(t *testing.T) {
+				req := httptest.NewRequest(method, uri, strings.NewReader("{}"))
+				req.Header.Set("Content-Type", "application/json")
+				if role != "" {
+					token, err := tokens.Issue("matrix-"+string(role), []auth.Role{role}, time.Minute)
+					if err != nil {
+						t.Fatal(err)
+					}
+					req.Header.Set("Authorization", "Bearer "+token)
+				}
+				var match mux.RouteMatch
+				if !mr.Match(req, &match) || match.Route == nil {
+					t.Fatalf("no route matches")
+				}
+				if path, _ := match.Route.GetPathTemplate(); path != route.Path {
+					t.Fatalf("the route %s matches instead of %s", path, route.Path)
+				}
+
+				rec := httptest.NewRecorder()
+				mr.ServeHTTP(rec, req)
+				switch want {
+				case "authorized":
+					if rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+						t.Errorf("status %d, the permission %s is granted: %s", rec.Code, route.Permission, rec.Body)
+					}
+				default:
+					if got := fmt.Sprint(rec.Code); got != want {
+						t.Errorf("status %s, want %s for the permission %s: %s", got, want, route.Permission, rec.Body)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestPreflight: the preflight requests are answered without credentials
+func TestPreflight(t *testing.T) {
+	mr, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodOptions, "/v2/books/1/purge", nil)
+	rec := httptest.NewRecorder()
+	mr.ServeHTTP(rec, req)
+	if rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+		t.Errorf("status %d of a preflight request", rec.Code)
+	}
+}
+
+func hasRole(roles []auth.Role, role auth.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"bookApp/internal/auth"
+	"bookApp/internal/ratelimit"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// v1Routes: registers the routes of the first version of the api, they are also served at the root as deprecated aliases
+func v1Routes(mr *mux.Router) {
+
+	// handlers regarding books, reading is public except for the soft deleted books, ordering, adding and deleting require the respective permission
+	// their queries are cancelled after QueryTimeout (SearchTimeout for name searches, which also have the smaller search budget)
+	b := mr.PathPrefix("/books").Subrouter()
+	permit(auth.PermPublic, b.HandleFunc("/", withDeadline(QueryTimeout, GetBooks)).Methods(http.MethodGet))
+	permit(auth.PermExportCatalog, b.HandleFunc("/all", withDeadline(QueryTimeout, GetBooksInludingDeleted)).Methods(http.MethodGet))
+	permit(auth.PermPublic, b.HandleFunc("/stock", withDeadline(QueryTimeout, GetBooksInStock)).Methods(http.MethodGet))
+	permit(auth.PermPublic, b.HandleFunc("/price/{priceunder}", withDeadline(QueryTimeout, GetBooksUnderPrice)).Methods(http.MethodGet))
+	permit(auth.PermPublic, b.HandleFunc("", withDeadline(QueryTimeout, GetBookByBookID)).Methods(http.MethodGet).Queries("id", "{id}"))
+	permit(auth.PermPublic, b.HandleFunc("", withDeadline(QueryTimeout, GetBookByISBN)).Methods(http.MethodGet).Queries("isbn", "{isbn}"))
+	limitAs(ratelimit.GroupSearch, permit(auth.PermPublic, b.HandleFunc("", withDeadline(SearchTimeout, GetBookByName)).Methods(http.MethodGet).Queries("name", "{name}")))
+	permit(auth.PermDeleteBooks, b.HandleFunc("/delete", withDeadline(QueryTimeout, DeleteBookById)).Methods(http.MethodDelete).Queries("id", "{id}"))
+	permit(auth.PermOrderBooks, b.HandleFunc("/order", withDeadline(QueryTimeout, BuyBookById)).Methods(http.MethodPatch).Queries("id", "{id}", "quantity", "{quantity}"))
+	permit(auth.PermWriteBooks, b.HandleFunc("/add", withDeadline(QueryTimeout, AddBookToDatabase)).Methods(http.MethodPost))
+
+	// handlers regarding authors
+	a := mr.PathPrefix("/authors").Subrouter()
+	permit(auth.PermPublic, a.HandleFunc("/", withDeadline(QueryTimeout, GetAuthorsWithBookInfo)).Methods(http.MethodGet))
+	permit(auth.PermPublic, a.HandleFunc("/*", withDeadline(QueryTimeout, GetAuthorsWithoutBookInfo)).Methods(http.MethodGet))
+	permit(auth.PermPublic, a.HandleFunc("", withDeadline(QueryTimeout, GetAuthorByID)).Methods(http.MethodGet).Queries("id", "{id}"))
+	limitAs(ratelimit.GroupSearch, permit(auth.PermPublic, a.HandleFunc("", withDeadline(SearchTimeout, GetAuthorByName)).Methods(http.MethodGet).Queries("name", "{name}")))
+	limitAs(ratelimit.GroupSearch, permit(auth.PermPublic, a.HandleFunc("/books", withDeadline(SearchTimeout, GetBooksOfAuthorByName)).Methods(http.MethodGet).Queries("name", "{name}")))
+
+	// handlers regarding bulk export of the catalogue, exports stream for as long as the client reads them so they have no deadline
+	// the soft deleted rows can be exported as well, so exports require the export permission
+	e := mr.PathPrefix("/export").Subrouter()
+	permit(auth.PermExportCatalog, e.HandleFunc("/books", ExportBooks).Methods(http.MethodGet))
+	permit(auth.PermExportCatalog, e.HandleFunc("/authors", ExportAuthors).Methods(http.MethodGet))
+
+	// audit trail of the changes of the books and authors
+	permit(auth.PermReadAudit, mr.HandleFunc("/audit", withDeadline(QueryTimeout, GetAuditTrail)).Methods(http.MethodGet).Queries("entity", "{entity}", "id", "{id}"))
+}
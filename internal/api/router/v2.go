@@ -0,0 +1,45 @@
+package router
+
+import (
+	"bookApp/internal/auth"
+	"bookApp/internal/ratelimit"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// v2Routes: registers the routes of the second version of the api, books and authors are resources addressed by their IDs
+// their bodies are the ones of the dto package, the v1 routes keep returning the entities as they did
+// the collections are filtered with query parameters, the routes with a filter are registered before the plain collection
+func v2Routes(mr *mux.Router) {
+
+	// handlers regarding books, reading is public, ordering, adding, adjusting the stock, deleting (and restoring) and purging require the respective permission
+	// their queries are cancelled after QueryTimeout (SearchTimeout for name searches, which also have the smaller search budget)
+	b := mr.PathPrefix("/books").Subrouter()
+	limitAs(ratelimit.GroupSearch, permit(auth.PermPublic, b.HandleFunc("", withDeadline(SearchTimeout, ListBooksByName)).Methods(http.MethodGet).Queries("name", "{name}")))
+	permit(auth.PermPublic, b.HandleFunc("", withDeadline(QueryTimeout, FindBookByISBN)).Methods(http.MethodGet).Queries("isbn", "{isbn}"))
+	permit(auth.PermPublic, b.HandleFunc("", withDeadline(QueryTimeout, ListBooksUnderPrice)).Methods(http.MethodGet).Queries("maxPrice", "{maxPrice}"))
+	permit(auth.PermPublic, b.HandleFunc("", withDeadline(QueryTimeout, ListBooks)).Methods(http.MethodGet))
+	permit(auth.PermWriteBooks, b.HandleFunc("", withDeadline(QueryTimeout, CreateBook)).Methods(http.MethodPost))
+	permit(auth.PermPublic, b.HandleFunc("/{id}", withDeadline(QueryTimeout, GetBook)).Methods(http.MethodGet))
+	permit(auth.PermDeleteBooks, b.HandleFunc("/{id}", withDeadline(QueryTimeout, DeleteBook)).Methods(http.MethodDelete))
+	permit(auth.PermDeleteBooks, b.HandleFunc("/{id}/restore", withDeadline(QueryTimeout, RestoreBook)).Methods(http.MethodPost))
+	permit(auth.PermOrderBooks, b.HandleFunc("/{id}/orders", withDeadline(QueryTimeout, OrderBook)).Methods(http.MethodPost))
+	permit(auth.PermAdjustStock, b.HandleFunc("/{id}/stock", withDeadline(QueryTimeout, AdjustStock)).Methods(http.MethodPut))
+	permit(auth.PermPurgeBooks, b.HandleFunc("/{id}/purge", withDeadline(QueryTimeout, PurgeBook)).Methods(http.MethodPost))
+
+	// handlers regarding authors, the books of an author are a sub collection
+	a := mr.PathPrefix("/authors").Subrouter()
+	limitAs(ratelimit.GroupSearch, permit(auth.PermPublic, a.HandleFunc("", withDeadline(SearchTimeout, ListAuthorsByName)).Methods(http.MethodGet).Queries("name", "{name}")))
+	permit(auth.PermPublic, a.HandleFunc("", withDeadline(QueryTimeout, ListAuthors)).Methods(http.MethodGet))
+	permit(auth.PermPublic, a.HandleFunc("/{id}", withDeadline(QueryTimeout, GetAuthor)).Methods(http.MethodGet))
+	permit(auth.PermPublic, a.HandleFunc("/{id}/books", withDeadline(QueryTimeout, ListAuthorBooks)).Methods(http.MethodGet))
+
+	// handlers regarding bulk export of the catalogue, see v1Routes
+	e := mr.PathPrefix("/export").Subrouter()
+	permit(auth.PermExportCatalog, e.HandleFunc("/books", ExportBooks).Methods(http.MethodGet))
+	permit(auth.PermExportCatalog, e.HandleFunc("/authors", ExportAuthors).Methods(http.MethodGet))
+
+	// audit trail of the changes of the books and authors
+	permit(auth.PermReadAudit, mr.HandleFunc("/audit", withDeadline(QueryTimeout, GetAuditTrail)).Methods(http.MethodGet).Queries("entity", "{entity}", "id", "{id}"))
+}
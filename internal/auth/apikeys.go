@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keyPrefix: prefix of the generated api keys, it makes a leaked key easy to recognize
+const keyPrefix = "bk_"
+
+// reloadInterval: how often the key file is checked for changes, so a revoked key stops working without a restart
+var reloadInterval = 5 * time.Second
+
+// APIKey: an api key of a service, only the sha256 hash of the key is stored
+// keys created without roles are readers
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Roles     []Role     `json:"roles,omitempty"`
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Revoked: reports whether the key is revoked
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+type keyFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// KeyStore: the api keys saved in a json file, the file is read again when it is changed
+type KeyStore struct {
+	path string
+
+	mu        sync.RWMutex
+	keys      []APIKey
+	byHash    map[string]APIKey
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// OpenKeyStore: reads the keys of the given file, a missing file is an empty store
+func OpenKeyStore(path string) (*KeyStore, error) {
+	s := &KeyStore{path: path}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *KeyStore) load() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.setKeys(nil, time.Time{})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	f := keyFile{}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("cannot parse api key file %s: %v", s.path, err)
+	}
+	s.setKeys(f.Keys, info.ModTime())
+	return nil
+}
+
+func (s *KeyStore) setKeys(keys []APIKey, modTime time.Time) {
+	byHash := make(map[string]APIKey, len(keys))
+	for _, k := range keys {
+		byHash[k.Hash] = k
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.byHash = byHash
+	s.modTime = modTime
+	s.checkedAt = time.Now()
+}
+
+// reloadIfChanged: reads the file again if it is modified since it was read, at most once per reload interval
+func (s *KeyStore) reloadIfChanged() {
+	s.mu.RLock()
+	due := time.Since(s.checkedAt) >= reloadInterval
+	modTime := s.modTime
+	s.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	info, err := os.Stat(s.path)
+	if err == nil && info.ModTime().Equal(modTime) {
+		s.mu.Lock()
+		s.checkedAt = time.Now()
+		s.mu.Unlock()
+		return
+	}
+	// a file that cannot be read keeps the keys read before
+	s.load()
+}
+
+// Authenticate: returns the principal of the given key if it is a valid key that is not revoked
+func (s *KeyStore) Authenticate(key string) (*Principal, error) {
+	s.reloadIfChanged()
+
+	s.mu.RLock()
+	k, ok := s.byHash[hashKey(key)]
+	s.mu.RUnlock()
+	if !ok || k.Revoked() {
+		return nil, ErrInvalidCredentials
+	}
+	roles := k.Roles
+	if len(roles) == 0 {
+		roles = []Role{RoleReader}
+	}
+	// the name is not unique, keys of the same service must not share their audit trail and rate limit
+	return &Principal{Subject: k.ID, Name: k.Name, Method: MethodAPIKey, Roles: roles}, nil
+}
+
+// List: returns the keys sorted by their creation time
+func (s *KeyStore) List() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := append([]APIKey{}, s.keys...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// Create: generates a new key for the named service with the given roles and saves it, the key itself is only returned once
+func (s *KeyStore) Create(name string, roles []Role) (APIKey, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return APIKey{}, "", fmt.Errorf("api key name is required")
+	}
+	id, err := randomString(6)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	key := keyPrefix + id + "_" + secret
+	k := APIKey{ID: id, Name: name, Roles: roles, Hash: hashKey(key), CreatedAt: time.Now().UTC()}
+
+	keys := append(s.List(), k)
+	if err := s.save(keys); err != nil {
+		return APIKey{}, "", err
+	}
+	return k, key, nil
+}
+
+// Revoke: revokes the key with the given id, a revoked key is kept in the file for the record
+func (s *KeyStore) Revoke(id string) (APIKey, error) {
+	keys := s.List()
+	for i, k := range keys {
+		if k.ID != id {
+			continue
+		}
+		if k.Revoked() {
+			return k, fmt.Errorf("api key %s is already revoked", id)
+		}
+		now := time.Now().UTC()
+		keys[i].RevokedAt = &now
+		if err := s.save(keys); err != nil {
+			return k, err
+		}
+		return keys[i], nil
+	}
+	return APIKey{}, fmt.Errorf("api key %s is not found", id)
+}
+
+// save: writes the keys to a temporary file first so that a running server never reads a partial file
+func (s *KeyStore) save(keys []APIKey) error {
+	data, err := json.MarshalIndent(keyFile{Keys: keys}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	return s.load()
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
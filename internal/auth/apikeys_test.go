@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// TestAPIKeys: a key authenticates as its ID with its roles until it is revoked, also in a store reading the same file
+func TestAPIKeys(t *testing.T) {
+	interval := reloadInterval
+	reloadInterval = 0
+	defer func() { reloadInterval = interval }()
+
+	path := filepath.Join(t.TempDir(), "apikeys.json")
+	store, err := OpenKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the store of a running server, it reads the file the keys command changes
+	server, err := OpenKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, readerKey, err := store.Create("catalogue", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a second key of the same service is another caller
+	clerk, clerkKey, err := store.Create("catalogue", []Role{RoleClerk})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		key   string
+		want  APIKey
+		roles []Role
+	}{
+		{readerKey, reader, []Role{RoleReader}},
+		{clerkKey, clerk, []Role{RoleClerk}},
+	} {
+		principal, err := server.Authenticate(tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if principal.Subject != tt.want.ID || principal.Name != "catalogue" || principal.Method != MethodAPIKey || !sameRoles(principal.Roles, tt.roles) {
+			t.Errorf("principal %+v, want the key %s with %v", principal, tt.want.ID, tt.roles)
+		}
+	}
+	if _, err := server.Authenticate("bk_unknown"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown key: error %v", err)
+	}
+
+	if _, err := store.Revoke(clerk.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Authenticate(clerkKey); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("revoked key: error %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, err := server.Authenticate(readerKey); err != nil {
+		t.Errorf("the key that is not revoked: %v", err)
+	}
+	if _, err := store.Revoke(clerk.ID); err == nil {
+		t.Error("a key is revoked twice")
+	}
+	if _, err := store.Revoke("unknown"); err == nil {
+		t.Error("an unknown key is revoked")
+	}
+	if _, _, err := store.Create(" ", nil); err == nil {
+		t.Error("a key without a name is created")
+	}
+}
+
+// TestAuthenticator: the credentials of a request, none is an anonymous caller and malformed ones are invalid
+func TestAuthenticator(t *testing.T) {
+	tokens, err := NewTokenIssuer(testSecret, "bookApp-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := tokens.Issue("alice", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Authenticator{Tokens: tokens}
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		invalid bool
+	}{
+		{"anonymous", "", "", false},
+		{"api key without a key store", APIKeyHeader, "bk_key", true},
+		{"basic authorization", "Authorization", "Basic YWxpY2U6c2VjcmV0", true},
+		{"bearer without a token", "Authorization", "Bearer ", true},
+		{"token expired on issue", "Authorization", "Bearer " + token, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			principal, err := a.Authenticate(r)
+			if tt.invalid != errors.Is(err, ErrInvalidCredentials) || (!tt.invalid && principal != nil) {
+				t.Errorf("principal %+v, error %v", principal, err)
+			}
+		})
+	}
+}
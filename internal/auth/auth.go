@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Authentication methods of a principal
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+	MethodCLI    = "cli"
+)
+
+// APIKeyHeader: header carrying the api key of a service
+const APIKeyHeader = "X-API-Key"
+
+var (
+	ErrMissingCredentials = errors.New("credentials are required")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrForbidden          = errors.New("permission denied")
+)
+
+// Principal: the authenticated caller of a request with its roles
+// the subject is unique to the caller, it is the ID of an api key and the subject of a token
+type Principal struct {
+	Subject string
+	// Name: the readable name of the caller if it is not the subject, e.g. the service name of an api key
+	Name   string
+	Method string
+	Roles  []Role
+}
+
+type contextKey struct{}
+
+// NewContext: returns a copy of the context carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext: returns the principal of the context, nil for anonymous requests
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Authenticator: identifies the callers by an api key (X-API-Key) or a bearer JWT (Authorization: Bearer)
+type Authenticator struct {
+	Keys   *KeyStore
+	Tokens *TokenIssuer
+}
+
+// Authenticate: returns the principal of the credentials of the request, nil if there are none
+// an error is returned if credentials are given but they are not valid
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		if a == nil || a.Keys == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return a.Keys.Authenticate(key)
+	}
+
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
+		return nil, ErrInvalidCredentials
+	}
+	if a == nil || a.Tokens == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return a.Tokens.Verify(strings.TrimSpace(parts[1]))
+}
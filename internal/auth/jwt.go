@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Claims: the claims of the user tokens, the subject identifies the user, users without roles are readers
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+}
+
+// TokenIssuer: signs and verifies the HS256 bearer tokens of the users
+type TokenIssuer struct {
+	secret []byte
+	issuer string
+}
+
+// NewTokenIssuer: creates an issuer with the shared secret, tokens of other issuers are rejected
+func NewTokenIssuer(secret, issuer string) (*TokenIssuer, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("jwt secret must be at least 32 characters")
+	}
+	return &TokenIssuer{secret: []byte(secret), issuer: issuer}, nil
+}
+
+// Issue: returns a signed token of the subject with the roles that expires after ttl
+func (t *TokenIssuer) Issue(subject string, roles []Role, ttl time.Duration) (string, error) {
+	if subject == "" {
+		return "", fmt.Errorf("token subject is required")
+	}
+	now := time.Now()
+	claims := Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   subject,
+		Issuer:    t.issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}}
+	for _, r := range roles {
+		claims.Roles = append(claims.Roles, string(r))
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.secret)
+}
+
+// Verify: returns the principal of a token signed by this issuer that is not expired
+func (t *TokenIssuer) Verify(token string) (*Principal, error) {
+	claims := Claims{}
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		// only HS256 is accepted, so a token cannot choose "none" or another algorithm
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Header["alg"])
+		}
+		return t.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if claims.ExpiresAt == nil || claims.Subject == "" || !claims.VerifyIssuer(t.issuer, true) {
+		return nil, fmt.Errorf("%w: token must have a subject, an expiry and the issuer %s", ErrInvalidCredentials, t.issuer)
+	}
+	// a token with an unknown role is rejected rather than silently losing the role
+	roles := []Role{}
+	for _, name := range claims.Roles {
+		r, err := ParseRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+		}
+		roles = append(roles, r)
+	}
+	if len(roles) == 0 {
+		roles = []Role{RoleReader}
+	}
+	return &Principal{Subject: claims.Subject, Method: MethodJWT, Roles: roles}, nil
+}
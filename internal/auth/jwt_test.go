@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// sign: signs the claims with the method and key, e.g. to forge the tokens the issuer must reject
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// claims: valid claims of the test issuer with the given roles
+func claims(roles ...string) Claims {
+	now := time.Now()
+	return Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "alice",
+		Issuer:    "bookApp-test",
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+	}, Roles: roles}
+}
+
+// TestVerify: only the unexpired HS256 tokens of the issuer with known roles are accepted
+func TestVerify(t *testing.T) {
+	issuer, err := NewTokenIssuer(testSecret, "bookApp-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued, err := issuer.Issue("alice", []Role{RoleClerk}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := claims("clerk")
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	noExpiry := claims("clerk")
+	noExpiry.ExpiresAt = nil
+	otherIssuer := claims("clerk")
+	otherIssuer.Issuer = "someone-else"
+	noSubject := claims("clerk")
+	noSubject.Subject = ""
+	notYet := claims("clerk")
+	notYet.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name  string
+		token string
+		roles []Role
+	}{
+		{"issued token", issued, []Role{RoleClerk}},
+		{"no roles are a reader", sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims()), []Role{RoleReader}},
+		{"roles in another case", sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims("Admin")), []Role{RoleAdmin}},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims("admin")), nil},
+		{"alg HS384 with the secret", sign(t, jwt.SigningMethodHS384, []byte(testSecret), claims("admin")), nil},
+		{"other secret", sign(t, jwt.SigningMethodHS256, []byte("another secret of at least 32 characters"), claims("admin")), nil},
+		{"tampered signature", issued[:len(issued)-2] + "xx", nil},
+		{"expired", sign(t, jwt.SigningMethodHS256, []byte(testSecret), expired), nil},
+		{"no expiry", sign(t, jwt.SigningMethodHS256, []byte(testSecret), noExpiry), nil},
+		{"not valid yet", sign(t, jwt.SigningMethodHS256, []byte(testSecret), notYet), nil},
+		{"other issuer", sign(t, jwt.SigningMethodHS256, []byte(testSecret), otherIssuer), nil},
+		{"no subject", sign(t, jwt.SigningMethodHS256, []byte(testSecret), noSubject), nil},
+		{"unknown role", sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims("clerk", "superuser")), nil},
+		{"not a token", "not-a-token", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := issuer.Verify(tt.token)
+			if tt.roles == nil {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Errorf("principal %+v, error %v, want %v", principal, err, ErrInvalidCredentials)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal.Subject != "alice" || principal.Method != MethodJWT || !sameRoles(principal.Roles, tt.roles) {
+				t.Errorf("principal %+v, want alice with %v", principal, tt.roles)
+			}
+		})
+	}
+}
+
+// TestNewTokenIssuer: a secret shorter than 32 characters is rejected
+func TestNewTokenIssuer(t *testing.T) {
+	if _, err := NewTokenIssuer("short", "bookApp"); err == nil {
+		t.Error("short secret is accepted")
+	}
+}
+
+func sameRoles(a, b []Role) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
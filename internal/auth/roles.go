@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Role: a set of permissions granted to api keys and users
+type Role string
+
+const (
+	RoleReader           Role = "reader"
+	RoleClerk            Role = "clerk"
+	RoleInventoryManager Role = "inventory_manager"
+	RoleAdmin            Role = "admin"
+)
+
+// Roles: every role from the least to the most privileged
+var Roles = []Role{RoleReader, RoleClerk, RoleInventoryManager, RoleAdmin}
+
+// Permission: an operation that is allowed to some roles
+type Permission string
+
+const (
+	// PermPublic: anyone can call the route, no credentials are needed
+	PermPublic Permission = "public"
+	// PermExportCatalog: export the whole catalogue and read the soft deleted books, they are not public
+	PermExportCatalog Permission = "catalog:export"
+	// PermOrderBooks: order books for a customer
+	PermOrderBooks Permission = "books:order"
+	// PermWriteBooks: add books to the catalogue
+	PermWriteBooks Permission = "books:write"
+	// PermAdjustStock: set the stock of the books to the counted stock
+	PermAdjustStock Permission = "stock:adjust"
+	// PermDeleteBooks: remove books from the catalogue and restore the removed ones
+	PermDeleteBooks Permission = "books:delete"
+	// PermPurgeBooks: permanently remove the deleted books from the database
+	PermPurgeBooks Permission = "books:purge"
+	// PermReadAudit: read the audit trail of the books and authors
+	PermReadAudit Permission = "audit:read"
+)
+
+// rolePermissions: the permission matrix, public routes need no role
+var rolePermissions = map[Role][]Permission{
+	RoleReader:           {},
+	RoleClerk:            {PermExportCatalog, PermOrderBooks},
+	RoleInventoryManager: {PermExportCatalog, PermOrderBooks, PermWriteBooks, PermAdjustStock, PermReadAudit},
+	RoleAdmin:            {PermExportCatalog, PermOrderBooks, PermWriteBooks, PermAdjustStock, PermDeleteBooks, PermPurgeBooks, PermReadAudit},
+}
+
+// ParseRole: parses the name of a role
+func ParseRole(s string) (Role, error) {
+	for _, r := range Roles {
+		if strings.EqualFold(s, string(r)) {
+			return r, nil
+		}
+	}
+	names := make([]string, len(Roles))
+	for i, r := range Roles {
+		names[i] = string(r)
+	}
+	return "", fmt.Errorf("unknown role %q, must be one of %s", s, strings.Join(names, ", "))
+}
+
+// ParseRoles: parses a comma separated list of roles
+func ParseRoles(s string) ([]Role, error) {
+	roles := []Role{}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		r, err := ParseRole(name)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	return roles, nil
+}
+
+// Grants: reports whether the role has the permission
+func (r Role) Grants(p Permission) bool {
+	if p == PermPublic {
+		return true
+	}
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesWith: returns the roles having the permission
+func RolesWith(p Permission) []Role {
+	roles := []Role{}
+	for _, r := range Roles {
+		if r.Grants(p) {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+// Can: reports whether any role of the principal has the permission
+func (p *Principal) Can(perm Permission) bool {
+	if perm == PermPublic {
+		return true
+	}
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r.Grants(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole: reports whether the principal has the role
+func (p *Principal) HasRole(role Role) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleNames: the sorted names of the roles of the principal
+func (p *Principal) RoleNames() []string {
+	if p == nil {
+		return nil
+	}
+	names := make([]string, len(p.Roles))
+	for i, r := range p.Roles {
+		names[i] = string(r)
+	}
+	sort.Strings(names)
+	return names
+}
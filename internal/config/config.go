@@ -0,0 +1,619 @@
+package config
+
+import (
+	"bookApp/internal/cors"
+	"bookApp/internal/ratelimit"
+	"bookApp/internal/tracing"
+	"bookApp/pkg/db"
+	"bookApp/pkg/logger"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config: settings of the app, populated from the defaults, a yaml config file, environment variables and flags in that precedence
+type Config struct {
+	Server    ServerConfig     `yaml:"server"`
+	Database  db.Config        `yaml:"database"`
+	Import    ImportConfig     `yaml:"import"`
+	Log       LogConfig        `yaml:"log"`
+	Tracing   tracing.Config   `yaml:"tracing"`
+	Auth      AuthConfig       `yaml:"auth"`
+	RateLimit ratelimit.Config `yaml:"rateLimit"`
+	CORS      cors.Config      `yaml:"cors"`
+	API       APIConfig        `yaml:"api"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"readTimeout"`
+	WriteTimeout    time.Duration `yaml:"writeTimeout"`
+	IdleTimeout     time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	DrainDelay      time.Duration `yaml:"drainDelay"`
+	QueryTimeout    time.Duration `yaml:"queryTimeout"`
+	SearchTimeout   time.Duration `yaml:"searchTimeout"`
+	TLS             TLSConfig     `yaml:"tls"`
+}
+
+// TLSConfig: the server is served over https if a certificate is set, the files are loaded again when they change
+type TLSConfig struct {
+	CertFile   string        `yaml:"certFile"`
+	KeyFile    string        `yaml:"keyFile"`
+	HSTSMaxAge time.Duration `yaml:"hstsMaxAge"`
+}
+
+// Enabled: reports whether the server is served over https
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+type ImportConfig struct {
+	SeedFile    string `yaml:"seedFile"`
+	ProfilesDir string `yaml:"profilesDir"`
+	Workers     int    `yaml:"workers"`
+	QueueSize   int    `yaml:"queueSize"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// AuthConfig: api keys are read from the keys file, bearer tokens are only accepted if a jwt secret is set
+type AuthConfig struct {
+	KeysFile  string        `yaml:"keysFile"`
+	JWTSecret string        `yaml:"jwtSecret"`
+	JWTIssuer string        `yaml:"jwtIssuer"`
+	TokenTTL  time.Duration `yaml:"tokenTTL"`
+}
+
+// APIConfig: the lifecycle of the api versions
+type APIConfig struct {
+	// LegacySunset: the date (YYYY-MM-DD) the deprecated root aliases of the v1 routes are removed
+	LegacySunset string `yaml:"legacySunset"`
+}
+
+// sunsetLayout: the layout of the sunset dates
+const sunsetLayout = "2006-01-02"
+
+// Sunset: returns the sunset date of the root aliases, the settings must be validated before
+func (a APIConfig) Sunset() time.Time {
+	t, _ := time.Parse(sunsetLayout, a.LegacySunset)
+	return t
+}
+
+// ConfigFileEnv: environment variable of the config file path, the -config flag takes precedence
+const ConfigFileEnv = "BOOK_APP_CONFIG"
+
+// Default: returns the settings used when nothing else is configured
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            "127.0.0.1:8090",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+			DrainDelay:      5 * time.Second,
+			QueryTimeout:    10 * time.Second,
+			SearchTimeout:   5 * time.Second,
+			TLS: TLSConfig{
+				HSTSMaxAge: 365 * 24 * time.Hour,
+			},
+		},
+		Database: db.Config{
+			Driver:           db.DriverPostgres,
+			Path:             "./bookApp.db",
+			Host:             "localhost",
+			Port:             "5432",
+			User:             "postgres",
+			Name:             "book_app_DB",
+			SSLMode:          "disable",
+			StatementTimeout: 30 * time.Second,
+			Pool: db.PoolConfig{
+				MaxOpenConns:    20,
+				MaxIdleConns:    5,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Health: db.HealthConfig{
+				Interval:   10 * time.Second,
+				Timeout:    2 * time.Second,
+				MaxBackoff: time.Minute,
+			},
+		},
+		Import: ImportConfig{
+			SeedFile:    "./pkg/docs/data.csv",
+			ProfilesDir: "./pkg/docs/profiles",
+			Workers:     3,
+			QueueSize:   5,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: logger.FormatText,
+		},
+		Tracing: tracing.Config{
+			Exporter:    tracing.ExporterNone,
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "bookApp",
+			SampleRatio: 1,
+		},
+		Auth: AuthConfig{
+			KeysFile:  "./apikeys.json",
+			JWTIssuer: "bookApp",
+			TokenTTL:  time.Hour,
+		},
+		RateLimit: ratelimit.Config{
+			Read:   ratelimit.Limit{Rate: 20, Burst: 40},
+			Search: ratelimit.Limit{Rate: 2, Burst: 10},
+			Write:  ratelimit.Limit{Rate: 1, Burst: 5},
+		},
+		CORS: cors.Config{
+			AllowedMethods: "GET, POST, PATCH, DELETE",
+			AllowedHeaders: "Authorization, Content-Type, X-API-Key, X-Request-ID",
+			ExposedHeaders: "X-Request-ID, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy",
+			MaxAge:         10 * time.Minute,
+		},
+		API: APIConfig{
+			LegacySunset: "2027-04-30",
+		},
+	}
+}
+
+// Logger: creates the logger of the log settings, the settings must be validated before
+func (c *Config) Logger() (*logger.Logger, error) {
+	level, err := logger.ParseLevel(c.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+	return logger.New(os.Stderr, level, c.Log.Format)
+}
+
+// setting: a single configurable value with its flag and environment variable
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	value flag.Value
+}
+
+// settings: binds every setting to the respective field of the given config
+func settings(c *Config) []setting {
+	return []setting{
+		{"server-addr", "BOOK_APP_ADDR", "address the http server listens on", (*stringValue)(&c.Server.Addr)},
+		{"server-read-timeout", "BOOK_APP_READ_TIMEOUT", "maximum duration for reading a request", (*durationValue)(&c.Server.ReadTimeout)},
+		{"server-write-timeout", "BOOK_APP_WRITE_TIMEOUT", "maximum duration for writing a response", (*durationValue)(&c.Server.WriteTimeout)},
+		{"server-idle-timeout", "BOOK_APP_IDLE_TIMEOUT", "maximum duration to keep an idle connection", (*durationValue)(&c.Server.IdleTimeout)},
+		{"server-shutdown-timeout", "BOOK_APP_SHUTDOWN_TIMEOUT", "maximum duration to wait for the requests on shutdown", (*durationValue)(&c.Server.ShutdownTimeout)},
+		{"server-drain-delay", "BOOK_APP_DRAIN_DELAY", "duration the readiness fails before the server is shut down", (*durationValue)(&c.Server.DrainDelay)},
+		{"server-query-timeout", "BOOK_APP_QUERY_TIMEOUT", "deadline of the database queries of a request, 0 for no deadline", (*durationValue)(&c.Server.QueryTimeout)},
+		{"server-search-timeout", "BOOK_APP_SEARCH_TIMEOUT", "deadline of the database queries of a name search, 0 for no deadline", (*durationValue)(&c.Server.SearchTimeout)},
+		{"server-tls-cert-file", "BOOK_APP_TLS_CERT_FILE", "pem certificate (chain) of the server, the server is served over https if set", (*stringValue)(&c.Server.TLS.CertFile)},
+		{"server-tls-key-file", "BOOK_APP_TLS_KEY_FILE", "pem private key of the certificate", (*stringValue)(&c.Server.TLS.KeyFile)},
+		{"server-tls-hsts-max-age", "BOOK_APP_TLS_HSTS_MAX_AGE", "max-age of the Strict-Transport-Security header sent over https, 0 to omit it", (*durationValue)(&c.Server.TLS.HSTSMaxAge)},
+		{"db-driver", "BOOK_APP_DB_DRIVER", "storage backend: postgres, sqlite or memory", (*stringValue)(&c.Database.Driver)},
+		{"db-path", "BOOK_APP_DB_PATH", "database file of the sqlite driver", (*stringValue)(&c.Database.Path)},
+		{"db-host", "BOOK_APP_HOST", "database host", (*stringValue)(&c.Database.Host)},
+		{"db-port", "BOOK_APP_PORT", "database port", (*stringValue)(&c.Database.Port)},
+		{"db-user", "BOOK_APP_USERNAME", "database user", (*stringValue)(&c.Database.User)},
+		{"db-name", "BOOK_APP_NAME", "database name", (*stringValue)(&c.Database.Name)},
+		{"db-password", "BOOK_APP_PASSWORD", "database password", (*stringValue)(&c.Database.Password)},
+		{"db-sslmode", "BOOK_APP_SSLMODE", "ssl mode of the database connection", (*stringValue)(&c.Database.SSLMode)},
+		{"db-statement-timeout", "BOOK_APP_DB_STATEMENT_TIMEOUT", "maximum duration of a statement on postgres, 0 for no limit", (*durationValue)(&c.Database.StatementTimeout)},
+		{"db-max-open-conns", "BOOK_APP_DB_MAX_OPEN_CONNS", "maximum number of open connections, 0 for no limit", (*intValue)(&c.Database.Pool.MaxOpenConns)},
+		{"db-max-idle-conns", "BOOK_APP_DB_MAX_IDLE_CONNS", "maximum number of idle connections", (*intValue)(&c.Database.Pool.MaxIdleConns)},
+		{"db-conn-max-lifetime", "BOOK_APP_DB_CONN_MAX_LIFETIME", "maximum duration a connection is reused, 0 for no limit", (*durationValue)(&c.Database.Pool.ConnMaxLifetime)},
+		{"db-conn-max-idle-time", "BOOK_APP_DB_CONN_MAX_IDLE_TIME", "maximum duration a connection stays idle, 0 for no limit", (*durationValue)(&c.Database.Pool.ConnMaxIdleTime)},
+		{"db-health-interval", "BOOK_APP_DB_HEALTH_INTERVAL", "interval of the database health probes", (*durationValue)(&c.Database.Health.Interval)},
+		{"db-health-timeout", "BOOK_APP_DB_HEALTH_TIMEOUT", "timeout of a database health probe", (*durationValue)(&c.Database.Health.Timeout)},
+		{"db-health-max-backoff", "BOOK_APP_DB_HEALTH_MAX_BACKOFF", "maximum interval of the probes while the database is unreachable", (*durationValue)(&c.Database.Health.MaxBackoff)},
+		{"import-seed-file", "BOOK_APP_SEED_FILE", "file loaded into the database by serve and seed, empty to skip", (*stringValue)(&c.Import.SeedFile)},
+		{"import-profiles-dir", "BOOK_APP_PROFILES_DIR", "directory of the saved csv profiles", (*stringValue)(&c.Import.ProfilesDir)},
+		{"import-workers", "BOOK_APP_IMPORT_WORKERS", "number of workers parsing a csv file", (*intValue)(&c.Import.Workers)},
+		{"import-queue-size", "BOOK_APP_IMPORT_QUEUE_SIZE", "number of csv rows queued for the workers", (*intValue)(&c.Import.QueueSize)},
+		{"log-level", "BOOK_APP_LOG_LEVEL", "minimum level of the logs: debug, info, warn or error", (*stringValue)(&c.Log.Level)},
+		{"log-format", "BOOK_APP_LOG_FORMAT", "format of the logs: text or json", (*stringValue)(&c.Log.Format)},
+		{"tracing-exporter", "BOOK_APP_TRACING_EXPORTER", "exporter of the trace spans: none, stdout or otlp", (*stringValue)(&c.Tracing.Exporter)},
+		{"tracing-endpoint", "BOOK_APP_TRACING_ENDPOINT", "OTLP/HTTP endpoint of the otlp exporter", (*stringValue)(&c.Tracing.Endpoint)},
+		{"tracing-service-name", "BOOK_APP_TRACING_SERVICE_NAME", "service name of the exported spans", (*stringValue)(&c.Tracing.ServiceName)},
+		{"tracing-sample-ratio", "BOOK_APP_TRACING_SAMPLE_RATIO", "ratio of the traces sampled when there is no sampled parent, between 0 and 1", (*floatValue)(&c.Tracing.SampleRatio)},
+		{"auth-keys-file", "BOOK_APP_API_KEYS_FILE", "file of the api keys managed by the keys command", (*stringValue)(&c.Auth.KeysFile)},
+		{"auth-jwt-secret", "BOOK_APP_JWT_SECRET", "HS256 secret of the bearer tokens, at least 32 characters, empty to accept api keys only", (*stringValue)(&c.Auth.JWTSecret)},
+		{"auth-jwt-issuer", "BOOK_APP_JWT_ISSUER", "issuer of the bearer tokens", (*stringValue)(&c.Auth.JWTIssuer)},
+		{"auth-token-ttl", "BOOK_APP_TOKEN_TTL", "default lifetime of the tokens issued by the token command", (*durationValue)(&c.Auth.TokenTTL)},
+		{"rate-limit-read-rate", "BOOK_APP_RATE_LIMIT_READ_RATE", "requests per second of a caller on the read routes, 0 for no limit", (*floatValue)(&c.RateLimit.Read.Rate)},
+		{"rate-limit-read-burst", "BOOK_APP_RATE_LIMIT_READ_BURST", "requests a caller can make at once on the read routes", (*intValue)(&c.RateLimit.Read.Burst)},
+		{"rate-limit-search-rate", "BOOK_APP_RATE_LIMIT_SEARCH_RATE", "requests per second of a caller on the name search routes, 0 for no limit", (*floatValue)(&c.RateLimit.Search.Rate)},
+		{"rate-limit-search-burst", "BOOK_APP_RATE_LIMIT_SEARCH_BURST", "requests a caller can make at once on the name search routes", (*intValue)(&c.RateLimit.Search.Burst)},
+		{"rate-limit-write-rate", "BOOK_APP_RATE_LIMIT_WRITE_RATE", "requests per second of a caller on the write routes, 0 for no limit", (*floatValue)(&c.RateLimit.Write.Rate)},
+		{"rate-limit-write-burst", "BOOK_APP_RATE_LIMIT_WRITE_BURST", "requests a caller can make at once on the write routes", (*intValue)(&c.RateLimit.Write.Burst)},
+		{"cors-allowed-origins", "BOOK_APP_CORS_ALLOWED_ORIGINS", "comma separated origins allowed to call the api from a browser (scheme://host, https://*.host or *), empty to disable CORS", (*stringValue)(&c.CORS.AllowedOrigins)},
+		{"cors-allowed-methods", "BOOK_APP_CORS_ALLOWED_METHODS", "comma separated methods allowed in the cross origin requests", (*stringValue)(&c.CORS.AllowedMethods)},
+		{"cors-allowed-headers", "BOOK_APP_CORS_ALLOWED_HEADERS", "comma separated headers allowed in the cross origin requests", (*stringValue)(&c.CORS.AllowedHeaders)},
+		{"cors-exposed-headers", "BOOK_APP_CORS_EXPOSED_HEADERS", "comma separated response headers readable by the browser scripts", (*stringValue)(&c.CORS.ExposedHeaders)},
+		{"cors-allow-credentials", "BOOK_APP_CORS_ALLOW_CREDENTIALS", "allow the cross origin requests with credentials", (*boolValue)(&c.CORS.AllowCredentials)},
+		{"cors-max-age", "BOOK_APP_CORS_MAX_AGE", "how long the browsers cache the preflight responses", (*durationValue)(&c.CORS.MaxAge)},
+		{"api-legacy-sunset", "BOOK_APP_API_LEGACY_SUNSET", "date (YYYY-MM-DD) announced in the Sunset header of the deprecated root routes", (*stringValue)(&c.API.LegacySunset)},
+		{"rate-limit-trusted-proxies", "BOOK_APP_RATE_LIMIT_TRUSTED_PROXIES", "comma separated addresses and cidr ranges of the proxies whose X-Forwarded-For header is trusted", (*stringValue)(&c.RateLimit.TrustedProxies)},
+	}
+}
+
+// Load: parses the flags in args and returns the config together with the arguments following the flags
+func Load(fs *flag.FlagSet, args []string) (*Config, []string, error) {
+	// flags are parsed into a throwaway config first, they are applied last to take precedence
+	parsed := Default()
+	configFile := fs.String("config", "", "yaml config file (env "+ConfigFileEnv+")")
+	for _, s := range settings(&parsed) {
+		fs.Var(s.value, s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	c := Default()
+	path := *configFile
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	problems := []string{}
+	for _, s := range settings(&c) {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.value.Set(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", s.env, err))
+			}
+		}
+	}
+	current := map[string]flag.Value{}
+	for _, s := range settings(&c) {
+		current[s.flag] = s.value
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if v, ok := current[f.Name]; ok {
+			v.Set(f.Value.String())
+		}
+	})
+
+	problems = append(problems, c.validate()...)
+	if len(problems) > 0 {
+		return nil, nil, &ValidationError{Problems: problems}
+	}
+	return &c, fs.Args(), nil
+}
+
+// loadFile: overrides the settings with the ones in the yaml file
+// a key that is not a setting (e.g. a typo) is an error instead of being ignored, an empty file changes nothing
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %v", err)
+	}
+	defer f.Close()
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("cannot parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// validate: returns every problem of the settings
+func (c *Config) validate() []string {
+	problems := []string{}
+
+	if _, port, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		problems = append(problems, fmt.Sprintf("server.addr %q must be host:port", c.Server.Addr))
+	} else if !validPort(port) {
+		problems = append(problems, fmt.Sprintf("server.addr %q has an invalid port", c.Server.Addr))
+	}
+	for name, d := range map[string]time.Duration{
+		"server.readTimeout":     c.Server.ReadTimeout,
+		"server.writeTimeout":    c.Server.WriteTimeout,
+		"server.idleTimeout":     c.Server.IdleTimeout,
+		"server.shutdownTimeout": c.Server.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			problems = append(problems, fmt.Sprintf("%s must be positive", name))
+		}
+	}
+	if c.Server.DrainDelay < 0 {
+		problems = append(problems, "server.drainDelay must not be negative")
+	}
+	for name, d := range map[string]time.Duration{
+		"server.queryTimeout":  c.Server.QueryTimeout,
+		"server.searchTimeout": c.Server.SearchTimeout,
+	} {
+		if d < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative", name))
+		} else if c.Server.WriteTimeout > 0 && d >= c.Server.WriteTimeout {
+			// the timeout response could not be written anymore
+			problems = append(problems, fmt.Sprintf("%s must be shorter than server.writeTimeout", name))
+		}
+	}
+
+	switch c.Database.Driver {
+	case db.DriverPostgres:
+		problems = append(problems, c.validatePostgres()...)
+	case db.DriverSqlite:
+		if c.Database.Path == "" {
+			problems = append(problems, "database.path is required for the sqlite driver")
+		}
+	case db.DriverMemory:
+	default:
+		problems = append(problems, fmt.Sprintf("database.driver %q must be one of postgres, sqlite, memory", c.Database.Driver))
+	}
+
+	problems = append(problems, c.validatePool()...)
+
+	if c.Import.Workers < 1 {
+		problems = append(problems, "import.workers must be at least 1")
+	}
+	if c.Import.QueueSize < 1 {
+		problems = append(problems, "import.queueSize must be at least 1")
+	}
+
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("log.level %q must be one of debug, info, warn, error", c.Log.Level))
+	}
+	if c.Log.Format != logger.FormatText && c.Log.Format != logger.FormatJSON {
+		problems = append(problems, fmt.Sprintf("log.format %q must be text or json", c.Log.Format))
+	}
+
+	problems = append(problems, c.validateTracing()...)
+
+	if c.Auth.KeysFile == "" {
+		problems = append(problems, "auth.keysFile is required")
+	}
+	if c.Auth.JWTSecret != "" && len(c.Auth.JWTSecret) < 32 {
+		problems = append(problems, "auth.jwtSecret must be at least 32 characters")
+	}
+	if c.Auth.JWTIssuer == "" {
+		problems = append(problems, "auth.jwtIssuer is required")
+	}
+	if c.Auth.TokenTTL <= 0 {
+		problems = append(problems, "auth.tokenTTL must be positive")
+	}
+
+	problems = append(problems, c.validateRateLimit()...)
+	problems = append(problems, c.validateTLS()...)
+
+	if _, err := cors.New(c.CORS); err != nil {
+		problems = append(problems, fmt.Sprintf("cors.allowedOrigins: %v", err))
+	}
+	if c.CORS.MaxAge < 0 {
+		problems = append(problems, "cors.maxAge must not be negative")
+	}
+
+	if _, err := time.Parse(sunsetLayout, c.API.LegacySunset); err != nil {
+		problems = append(problems, fmt.Sprintf("api.legacySunset %q must be a date as YYYY-MM-DD", c.API.LegacySunset))
+	}
+
+	// maps are iterated in random order, keep the report stable
+	sort.Strings(problems)
+	return problems
+}
+
+// validateTracing: returns the problems of the tracing settings
+func (c *Config) validateTracing() []string {
+	problems := []string{}
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	case tracing.ExporterOTLP:
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			problems = append(problems, fmt.Sprintf("tracing.endpoint %q must be an http or https url", c.Tracing.Endpoint))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter %q must be one of none, stdout, otlp", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sampleRatio must be between 0 and 1")
+	}
+	if c.Tracing.ServiceName == "" {
+		problems = append(problems, "tracing.serviceName is required")
+	}
+	return problems
+}
+
+// validateRateLimit: returns the problems of the rate limit settings
+func (c *Config) validateRateLimit() []string {
+	problems := []string{}
+	for name, limit := range map[string]ratelimit.Limit{
+		"rateLimit.read":   c.RateLimit.Read,
+		"rateLimit.search": c.RateLimit.Search,
+		"rateLimit.write":  c.RateLimit.Write,
+	} {
+		if limit.Rate < 0 {
+			problems = append(problems, fmt.Sprintf("%s.rate must not be negative", name))
+		} else if !limit.Unlimited() && limit.Burst < 1 {
+			problems = append(problems, fmt.Sprintf("%s.burst must be at least 1", name))
+		}
+	}
+	if _, err := ratelimit.ParseProxies(c.RateLimit.TrustedProxies); err != nil {
+		problems = append(problems, fmt.Sprintf("rateLimit.trustedProxies: %v", err))
+	}
+	return problems
+}
+
+// validateTLS: returns the problems of the tls settings, the certificate itself is loaded when the server starts
+func (c *Config) validateTLS() []string {
+	problems := []string{}
+	t := c.Server.TLS
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		problems = append(problems, "server.tls.certFile and server.tls.keyFile must be set together")
+	}
+	if t.HSTSMaxAge < 0 {
+		problems = append(problems, "server.tls.hstsMaxAge must not be negative")
+	}
+	return problems
+}
+
+// validatePostgres: returns the problems of the postgres connection settings
+func (c *Config) validatePostgres() []string {
+	problems := []string{}
+	for name, value := range map[string]string{
+		"database.host": c.Database.Host,
+		"database.user": c.Database.User,
+		"database.name": c.Database.Name,
+	} {
+		if value == "" {
+			problems = append(problems, fmt.Sprintf("%s is required", name))
+		}
+	}
+	if !validPort(c.Database.Port) {
+		problems = append(problems, fmt.Sprintf("database.port %q must be a number between 1 and 65535", c.Database.Port))
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, fmt.Sprintf("database.sslmode %q must be one of disable, allow, prefer, require, verify-ca, verify-full", c.Database.SSLMode))
+	}
+	return problems
+}
+
+// validatePool: returns the problems of the pool and health settings of the database
+func (c *Config) validatePool() []string {
+	problems := []string{}
+	pool := c.Database.Pool
+	if pool.MaxOpenConns < 0 {
+		problems = append(problems, "database.pool.maxOpenConns must not be negative")
+	}
+	if pool.MaxIdleConns < 0 {
+		problems = append(problems, "database.pool.maxIdleConns must not be negative")
+	}
+	if pool.MaxOpenConns > 0 && pool.MaxIdleConns > pool.MaxOpenConns {
+		problems = append(problems, "database.pool.maxIdleConns must not be greater than database.pool.maxOpenConns")
+	}
+	for name, d := range map[string]time.Duration{
+		"database.statementTimeout":     c.Database.StatementTimeout,
+		"database.pool.connMaxLifetime": pool.ConnMaxLifetime,
+		"database.pool.connMaxIdleTime": pool.ConnMaxIdleTime,
+	} {
+		if d < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative", name))
+		}
+	}
+
+	health := c.Database.Health
+	if health.Interval <= 0 {
+		problems = append(problems, "database.health.interval must be positive")
+	}
+	if health.Timeout <= 0 {
+		problems = append(problems, "database.health.timeout must be positive")
+	}
+	if health.MaxBackoff < health.Interval {
+		problems = append(problems, "database.health.maxBackoff must not be less than database.health.interval")
+	}
+	return problems
+}
+
+func validPort(port string) bool {
+	p, err := strconv.Atoi(port)
+	return err == nil && p > 0 && p <= 65535
+}
+
+// ValidationError: lists everything that is wrong with the settings
+type ValidationError struct {
+	Problems []string
+}
+
+func (v *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(v.Problems, "\n  - ")
+}
+
+// flag.Value implementations bound to the fields of a config
+
+type stringValue string
+
+func (s *stringValue) Set(v string) error { *s = stringValue(v); return nil }
+func (s *stringValue) String() string {
+	if s == nil {
+		return ""
+	}
+	return string(*s)
+}
+
+type boolValue bool
+
+func (b *boolValue) Set(v string) error {
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", v)
+	}
+	*b = boolValue(parsed)
+	return nil
+}
+func (b *boolValue) String() string {
+	if b == nil {
+		return "false"
+	}
+	return strconv.FormatBool(bool(*b))
+}
+
+// IsBoolFlag: the flag can be given without a value
+func (b *boolValue) IsBoolFlag() bool { return true }
+
+type intValue int
+
+func (i *intValue) Set(v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", v)
+	}
+	*i = intValue(n)
+	return nil
+}
+func (i *intValue) String() string {
+	if i == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*i))
+}
+
+type floatValue float64
+
+func (f *floatValue) Set(v string) error {
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", v)
+	}
+	*f = floatValue(n)
+	return nil
+}
+func (f *floatValue) String() string {
+	if f == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(float64(*f), 'g', -1, 64)
+}
+
+type durationValue time.Duration
+
+func (d *durationValue) Set(v string) error {
+	parsed, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration", v)
+	}
+	*d = durationValue(parsed)
+	return nil
+}
+func (d *durationValue) String() string {
+	if d == nil {
+		return "0s"
+	}
+	return time.Duration(*d).String()
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv: unsets the environment variables of the settings for the test, so that the environment of the shell is not loaded
+func clearEnv(t *testing.T) {
+	t.Helper()
+	envs := []string{ConfigFileEnv}
+	for _, s := range settings(&Config{}) {
+		envs = append(envs, s.env)
+	}
+	for _, env := range envs {
+		env := env
+		if value, ok := os.LookupEnv(env); ok {
+			os.Unsetenv(env)
+			t.Cleanup(func() { os.Setenv(env, value) })
+		}
+	}
+}
+
+// load: loads the config with the given flags, the environment variables are set by the test
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, _, err := Load(fs, args)
+	return c, err
+}
+
+// writeFile: writes a config file to the directory of the test
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadPrecedence: every source overrides the settings of the ones before it, defaults < file < environment < flags
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+server:
+  addr: 127.0.0.1:1000
+log:
+  level: warn
+import:
+  workers: 4
+  queueSize: 7
+`)
+	clearEnv(t)
+	t.Setenv(ConfigFileEnv, path)
+	t.Setenv("BOOK_APP_LOG_LEVEL", "debug")
+	t.Setenv("BOOK_APP_IMPORT_WORKERS", "6")
+
+	c, err := load(t, "-import-workers", "8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"default", c.Server.ReadTimeout, 15 * time.Second},
+		{"file over default", c.Server.Addr, "127.0.0.1:1000"},
+		{"file over default", c.Import.QueueSize, 7},
+		{"environment over file", c.Log.Level, "debug"},
+		{"flag over environment", c.Import.Workers, 8},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+// TestLoadConfigFlag: the -config flag takes precedence over the environment variable of the config file
+func TestLoadConfigFlag(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(ConfigFileEnv, writeFile(t, "log:\n  level: warn\n"))
+	c, err := load(t, "-config", writeFile(t, "log:\n  level: error\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Log.Level != "error" {
+		t.Errorf("log level %s of the file of the environment", c.Log.Level)
+	}
+}
+
+// TestLoadFile: the keys of a config file must be settings, the example file only has settings
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"example", "", ""},
+		{"empty file", "", ""},
+		{"comments only", "# nothing is set\n", ""},
+		{"misspelled key", "server:\n  adr: 127.0.0.1:1000\n", "field adr not found"},
+		{"unknown section", "metrics:\n  enabled: true\n", "field metrics not found"},
+		{"wrong type", "import:\n  workers: many\n", "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join("..", "..", "config.example.yaml")
+			if tt.name != "example" {
+				path = writeFile(t, tt.content)
+			}
+			c := Default()
+			err := c.loadFile(path)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestLoadValidation: every problem of the environment, the flags and the settings is reported at once
+func TestLoadValidation(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("BOOK_APP_IMPORT_WORKERS", "many")
+	t.Setenv("BOOK_APP_LOG_LEVEL", "loud")
+
+	_, err := load(t, "-db-driver", "oracle", "-import-queue-size", "0", "-auth-jwt-secret", "short")
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("error %v, want a ValidationError", err)
+	}
+	want := []string{
+		`BOOK_APP_IMPORT_WORKERS: "many" is not a number`,
+		`log.level "loud" must be one of debug, info, warn, error`,
+		`database.driver "oracle" must be one of postgres, sqlite, memory`,
+		"import.queueSize must be at least 1",
+		"auth.jwtSecret must be at least 32 characters",
+	}
+	if len(invalid.Problems) != len(want) {
+		t.Errorf("problems:\n%s", invalid)
+	}
+	for _, problem := range want {
+		if !strings.Contains(invalid.Error(), problem) {
+			t.Errorf("problem %q is missing:\n%s", problem, invalid)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config: the origins allowed to call the api from a browser and what their requests may contain
+// the lists are comma separated, no allowed origin disables CORS
+type Config struct {
+	AllowedOrigins   string        `yaml:"allowedOrigins"`
+	AllowedMethods   string        `yaml:"allowedMethods"`
+	AllowedHeaders   string        `yaml:"allowedHeaders"`
+	ExposedHeaders   string        `yaml:"exposedHeaders"`
+	AllowCredentials bool          `yaml:"allowCredentials"`
+	MaxAge           time.Duration `yaml:"maxAge"`
+}
+
+// Policy: the parsed config answering the preflight and the actual requests of the browsers
+type Policy struct {
+	anyOrigin bool
+	origins   map[string]struct{}
+	// suffixes: the origins allowed by a wildcard subdomain, e.g. https://*.example.com is stored as scheme https and .example.com
+	suffixes    []originSuffix
+	methods     map[string]struct{}
+	headers     map[string]struct{}
+	allowMethod string
+	allowHeader string
+	expose      string
+	credentials bool
+	maxAge      string
+}
+
+type originSuffix struct {
+	scheme string
+	suffix string
+}
+
+// New: parses the config, the returned policy is nil if no origin is allowed
+func New(c Config) (*Policy, error) {
+	origins := split(c.AllowedOrigins)
+	if len(origins) == 0 {
+		return nil, nil
+	}
+	p := &Policy{
+		origins:     map[string]struct{}{},
+		methods:     map[string]struct{}{},
+		headers:     map[string]struct{}{},
+		credentials: c.AllowCredentials,
+		maxAge:      strconv.Itoa(int(c.MaxAge.Seconds())),
+	}
+	for _, origin := range origins {
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			parts := strings.SplitN(origin, "://*", 2)
+			p.suffixes = append(p.suffixes, originSuffix{scheme: strings.ToLower(parts[0]), suffix: strings.ToLower(parts[1])})
+		default:
+			u, err := url.Parse(origin)
+			if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+				return nil, fmt.Errorf("allowed origin %q must be scheme://host[:port], *.host or *", origin)
+			}
+			p.origins[strings.ToLower(u.Scheme+"://"+u.Host)] = struct{}{}
+		}
+	}
+	if p.anyOrigin && p.credentials {
+		return nil, fmt.Errorf("credentials cannot be allowed for any origin (*)")
+	}
+
+	methods := []string{}
+	for _, m := range split(c.AllowedMethods) {
+		m = strings.ToUpper(m)
+		p.methods[m] = struct{}{}
+		methods = append(methods, m)
+	}
+	headers := []string{}
+	for _, h := range split(c.AllowedHeaders) {
+		p.headers[strings.ToLower(h)] = struct{}{}
+		headers = append(headers, h)
+	}
+	p.allowMethod = strings.Join(methods, ", ")
+	p.allowHeader = strings.Join(headers, ", ")
+	p.expose = strings.Join(split(c.ExposedHeaders), ", ")
+	return p, nil
+}
+
+// AllowOrigin: reports whether requests of the origin are allowed
+func (p *Policy) AllowOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if _, ok := p.origins[origin]; ok {
+		return true
+	}
+	for _, s := range p.suffixes {
+		if strings.HasPrefix(origin, s.scheme+"://") && strings.HasSuffix(origin, s.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Actual: sets the headers of the response to an actual (not preflight) request of the origin
+// the origin must be allowed, Vary is set by the caller for every response
+func (p *Policy) Actual(h http.Header, origin string) {
+	p.allowOriginHeaders(h, origin)
+	if p.expose != "" {
+		h.Set("Access-Control-Expose-Headers", p.expose)
+	}
+}
+
+// Preflight: sets the headers allowing the method and headers requested by the preflight request of the origin
+// nothing is set if the origin, the method or one of the headers is not allowed, the browser blocks the request then
+func (p *Policy) Preflight(h http.Header, origin, method, requestHeaders string) bool {
+	if !p.AllowOrigin(origin) {
+		return false
+	}
+	if _, ok := p.methods[strings.ToUpper(method)]; !ok {
+		return false
+	}
+	for _, header := range split(requestHeaders) {
+		if _, ok := p.headers[strings.ToLower(header)]; !ok {
+			return false
+		}
+	}
+	p.allowOriginHeaders(h, origin)
+	h.Set("Access-Control-Allow-Methods", p.allowMethod)
+	if p.allowHeader != "" {
+		h.Set("Access-Control-Allow-Headers", p.allowHeader)
+	}
+	if p.maxAge != "0" {
+		h.Set("Access-Control-Max-Age", p.maxAge)
+	}
+	return true
+}
+
+func (p *Policy) allowOriginHeaders(h http.Header, origin string) {
+	if p.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// split: the trimmed non empty items of a comma separated list
+func split(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,94 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// AuditAction: the kind of change of a catalogue mutation
+type AuditAction string
+
+const (
+	AuditCreate   AuditAction = "create"
+	AuditUpdate   AuditAction = "update"
+	AuditDelete   AuditAction = "delete"
+	AuditPurge    AuditAction = "purge"
+	AuditRestore  AuditAction = "restore"
+	AuditPurchase AuditAction = "purchase"
+	AuditImport   AuditAction = "import"
+)
+
+// entities that are audited, EntityID of their entries is the string ID of the book or author
+const (
+	AuditEntityBook   = "book"
+	AuditEntityAuthor = "author"
+)
+
+// AuditEntry: a change of a book or author, who made it, in which request and when
+// entries are only ever inserted, they are never updated or deleted by the app
+type AuditEntry struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time    `json:"timestamp"`
+	Action     AuditAction  `json:"action"`
+	Entity     string       `json:"entity"`
+	EntityID   string       `json:"entityId"`
+	Actor      string       `json:"actor"`
+	AuthMethod string       `json:"authMethod,omitempty"`
+	RequestID  string       `json:"requestId,omitempty"`
+	Changes    AuditChanges `json:"changes" gorm:"type:text"`
+}
+
+// AuditChange: the value of a field before and after the change, nil if the entity did not exist before or after
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges: the changed fields of an entity by their json names, stored as a json column
+type AuditChanges map[string]AuditChange
+
+// Value: stores the changes as json
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		c = AuditChanges{}
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan: reads the changes stored as json
+func (c *AuditChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = AuditChanges{}
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	}
+	return fmt.Errorf("cannot scan %T into audit changes", value)
+}
+
+// Diff: returns the fields whose values differ between the two field sets, nil stands for an entity that does not exist
+func Diff(before, after map[string]interface{}) AuditChanges {
+	changes := AuditChanges{}
+	for field, b := range before {
+		a, ok := after[field]
+		if !ok || !reflect.DeepEqual(a, b) {
+			changes[field] = AuditChange{Before: b, After: a}
+		}
+	}
+	for field, a := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = AuditChange{After: a}
+		}
+	}
+	return changes
+}
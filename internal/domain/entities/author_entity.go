@@ -30,3 +30,10 @@ func (a *Author) ToStringWithBooks() string {
 
 	return fmt.Sprintf("Author: %s has books:\n%s", authorInfo, bookInfoJoined)
 }
+
+// AuditFields: the fields of the author recorded by the audit trail by their json names
+func (a *Author) AuditFields() map[string]interface{} {
+	return map[string]interface{}{
+		"name": a.Name,
+	}
+}
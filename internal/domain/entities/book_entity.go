@@ -1,6 +1,8 @@
 package entities
 
 import (
+	"bookApp/pkg/logger"
+	"context"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -25,20 +27,46 @@ func (b *Book) ToString() string {
 	return fmt.Sprintf("ID: %s, Name: %s, Page Number: %d, Stock Number: %d, StockID: %s, Price: %.2f, ISBN: %s, Author ID: %s\n", b.ID, b.Name, b.PageNumber, b.StockNumber, b.StockID, b.Price, b.ISBN, b.AuthorID)
 }
 
-// BeforeDelete: Print book name before deleting.
+// BeforeDelete: Log the book before deleting with the fields of the request deleting it.
 func (b *Book) BeforeDelete(tx *gorm.DB) error {
-	fmt.Printf("Book %s is deleting...\n", b.Name)
-	fmt.Println(b.ToString())
+	logger.FromContext(tx.Statement.Context).Info("book is deleting", b.logFields()...)
 	return nil
 }
 
-// AfterDelete: Print book name after it is deleted with a success message.
+// AfterDelete: Log the book after it is deleted with a success message.
 func (b *Book) AfterDelete(tx *gorm.DB) error {
-	fmt.Printf("Book %s is succesfully deleted...\n", b.Name)
+	logger.FromContext(tx.Statement.Context).Info("book is successfully deleted", "book_id", b.ID, "name", b.Name)
 	return nil
 }
 
-// AfterOrder: Print book name after it is ordered with a success message.
-func (b *Book) AfterOrder(num int) {
-	fmt.Printf("Book %s of quantity %d is succesfully ordered...\n", b.Name, num)
+// AfterOrder: Log the book after it is ordered with a success message, the stock number is already decreased.
+func (b *Book) AfterOrder(ctx context.Context, num int) {
+	logger.FromContext(ctx).Info("book is successfully ordered", "book_id", b.ID, "name", b.Name, "quantity", num, "stock_left", b.StockNumber)
+}
+
+// logFields: the fields of the book as key value pairs of a log entry
+func (b *Book) logFields() []interface{} {
+	return []interface{}{
+		"book_id", b.ID,
+		"name", b.Name,
+		"page_number", b.PageNumber,
+		"stock_number", b.StockNumber,
+		"stock_id", b.StockID,
+		"price", b.Price,
+		"isbn", b.ISBN,
+		"author_id", b.AuthorID,
+	}
+}
+
+// AuditFields: the fields of the book recorded by the audit trail by their json names
+func (b *Book) AuditFields() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        b.Name,
+		"pageNumber":  b.PageNumber,
+		"stockNumber": b.StockNumber,
+		"stockId":     b.StockID,
+		"price":       b.Price,
+		"isbn":        b.ISBN,
+		"authorID":    b.AuthorID,
+	}
 }
@@ -0,0 +1,191 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration: a versioned change of the database schema with its rollback
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration: a row of the schema table recording an applied migration
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName: name of the schema table
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status: state of a migration in the database
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator: creates a migrator with all the migrations of the app
+func NewMigrator(db *gorm.DB) *Migrator {
+	return NewMigratorWith(db, All)
+}
+
+// NewMigratorWith: creates a migrator with the given migrations, sorted by their versions
+func NewMigratorWith(db *gorm.DB, migrations []Migration) *Migrator {
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// ensureSchemaTable: creates the schema table if it does not exist
+func (m *Migrator) ensureSchemaTable() error {
+	if m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil
+	}
+	return m.db.Migrator().CreateTable(&SchemaMigration{})
+}
+
+// applied: returns the applied migrations by their versions, none if the schema table does not exist
+// the schema table is only created by Up, reading the state never changes the database
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return map[int]SchemaMigration{}, nil
+	}
+	rows := []SchemaMigration{}
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status: returns the state of every migration
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending: returns the migrations that are not applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// CountPending: returns the number of migrations that are not applied yet without changing the database
+// all the migrations are pending if the schema table does not exist
+func (m *Migrator) CountPending(ctx context.Context) (int, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return len(m.migrations), nil
+	}
+	versions := []int{}
+	if err := db.Model(&SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return 0, err
+	}
+	applied := make(map[int]struct{}, len(versions))
+	for _, v := range versions {
+		applied[v] = struct{}{}
+	}
+	pending := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up: applies the pending migrations in order, every migration runs in its own transaction
+// and returns the applied migrations
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.ensureSchemaTable(); err != nil {
+		return nil, fmt.Errorf("cannot create schema table: %v", err)
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	done := []Migration{}
+	for _, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s failed: %v", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down: rolls back the last given number of applied migrations in reverse order
+// and returns the rolled back migrations
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	done := []Migration{}
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback of migration %d_%s failed: %v", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
@@ -0,0 +1,188 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB: an empty in-memory database of its own for every test
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the in-memory database lives as long as its connection
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// tableMigration: a migration creating a table of the given name
+func tableMigration(version int, table string) Migration {
+	type row struct{ ID int }
+	return Migration{
+		Version: version,
+		Name:    "create_" + table,
+		Up: func(tx *gorm.DB) error {
+			return tx.Table(table).Migrator().CreateTable(&row{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(table)
+		},
+	}
+}
+
+func versions(migrations []Migration) []int {
+	v := make([]int, len(migrations))
+	for i, m := range migrations {
+		v[i] = m.Version
+	}
+	return v
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestStatusIsReadOnly: the state of a database without the schema table is read without creating it
+func TestStatusIsReadOnly(t *testing.T) {
+	db := testDB(t)
+	m := NewMigrator(db)
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Applied || s.AppliedAt != nil {
+			t.Errorf("migration %d is applied", s.Version)
+		}
+	}
+	pending, err := m.Pending()
+	if err != nil || len(pending) != len(All) {
+		t.Errorf("%d pending, error %v, want %d", len(pending), err, len(All))
+	}
+	count, err := m.CountPending(context.Background())
+	if err != nil || count != len(All) {
+		t.Errorf("%d pending, error %v, want %d", count, err, len(All))
+	}
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		t.Error("the schema table is created by reading the state")
+	}
+}
+
+// TestUpDown: the migrations are applied in the order of their versions and rolled back in reverse order
+func TestUpDown(t *testing.T) {
+	db := testDB(t)
+	m := NewMigratorWith(db, []Migration{tableMigration(3, "third"), tableMigration(1, "first"), tableMigration(2, "second")})
+
+	done, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(done); !equalInts(got, []int{1, 2, 3}) {
+		t.Errorf("applied %v, want [1 2 3]", got)
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.AppliedAt == nil {
+			t.Errorf("migration %d is not applied", s.Version)
+		}
+	}
+
+	done, err = m.Down(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(done); !equalInts(got, []int{3, 2}) {
+		t.Errorf("rolled back %v, want [3 2]", got)
+	}
+	for table, exists := range map[string]bool{"first": true, "second": false, "third": false} {
+		if db.Migrator().HasTable(table) != exists {
+			t.Errorf("table %s exists: %v, want %v", table, !exists, exists)
+		}
+	}
+	if count, err := m.CountPending(context.Background()); err != nil || count != 2 {
+		t.Errorf("%d pending, error %v, want 2", count, err)
+	}
+
+	if done, err = m.Up(); err != nil || !equalInts(versions(done), []int{2, 3}) {
+		t.Errorf("applied %v, error %v, want [2 3]", versions(done), err)
+	}
+	if done, err = m.Up(); err != nil || len(done) != 0 {
+		t.Errorf("applied %v again, error %v", versions(done), err)
+	}
+}
+
+// TestUpFailure: a failing migration is rolled back and not recorded, the migrations before it stay applied
+func TestUpFailure(t *testing.T) {
+	db := testDB(t)
+	failing := tableMigration(2, "second")
+	up := failing.Up
+	failing.Up = func(tx *gorm.DB) error {
+		if err := up(tx); err != nil {
+			return err
+		}
+		return errors.New("broken")
+	}
+	m := NewMigratorWith(db, []Migration{tableMigration(1, "first"), failing, tableMigration(3, "third")})
+
+	done, err := m.Up()
+	if err == nil || !strings.Contains(err.Error(), "migration 2_create_second failed: broken") {
+		t.Fatalf("error %v", err)
+	}
+	if got := versions(done); !equalInts(got, []int{1}) {
+		t.Errorf("applied %v, want [1]", got)
+	}
+	if db.Migrator().HasTable("second") || db.Migrator().HasTable("third") {
+		t.Error("the failed migration or the ones after it changed the schema")
+	}
+	if count, err := m.CountPending(context.Background()); err != nil || count != 2 {
+		t.Errorf("%d pending, error %v, want 2", count, err)
+	}
+}
+
+// TestAll: the migrations of the app are applied to an empty database and rolled back completely
+func TestAll(t *testing.T) {
+	db := testDB(t)
+	m := NewMigrator(db)
+	if done, err := m.Up(); err != nil || len(done) != len(All) {
+		t.Fatalf("applied %v, error %v", versions(done), err)
+	}
+	for _, table := range []string{"authors", "books", "audit_entries"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s is missing", table)
+		}
+	}
+	if done, err := m.Down(len(All)); err != nil || len(done) != len(All) {
+		t.Fatalf("rolled back %v, error %v", versions(done), err)
+	}
+	for _, table := range []string{"authors", "books", "audit_entries"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("table %s is not dropped", table)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// All: migrations of the app, a released migration must never be changed, add a new one instead
+var All = []Migration{
+	{
+		Version: 1,
+		Name:    "create_authors_and_books",
+		Up: func(tx *gorm.DB) error {
+			// AutoMigrate is used so that databases created by earlier versions of the app are adopted as they are
+			return tx.AutoMigrate(&author0001{}, &book0001{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&book0001{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&author0001{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create_audit_entries",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&auditEntry0002{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&auditEntry0002{})
+		},
+	},
+}
+
+// author0001 and book0001: the schema of the authors and books tables as of migration 1
+type author0001 struct {
+	gorm.Model
+	ID    string `gorm:"unique"`
+	Name  string
+	Books []book0001 `gorm:"foreignKey:AuthorID;references:ID"`
+}
+
+func (author0001) TableName() string {
+	return "authors"
+}
+
+type book0001 struct {
+	gorm.Model
+	ID          string `gorm:"unique"`
+	Name        string
+	PageNumber  uint
+	StockNumber int
+	StockID     string `gorm:"unique"`
+	Price       float32
+	ISBN        string
+	AuthorID    string
+	Author      *author0001 `gorm:"foreignKey:AuthorID;references:ID"`
+}
+
+func (book0001) TableName() string {
+	return "books"
+}
+
+// auditEntry0002: the schema of the audit_entries table as of migration 2, entries are looked up by their entity
+type auditEntry0002 struct {
+	ID         uint `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	Action     string
+	Entity     string `gorm:"index:idx_audit_entries_entity"`
+	EntityID   string `gorm:"index:idx_audit_entries_entity"`
+	Actor      string
+	AuthMethod string
+	RequestID  string
+	Changes    string `gorm:"type:text"`
+}
+
+func (auditEntry0002) TableName() string {
+	return "audit_entries"
+}
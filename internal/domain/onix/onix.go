@@ -0,0 +1,386 @@
+package onix
+
+import (
+	"bookApp/internal/domain/entities"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const Namespace = "http://ns.editeur.org/onix/3.0/reference"
+
+// ErrShortTags: the message uses the short tags of ONIX (<ONIXmessage>, <product>, <a001>...), only the reference tags are read
+var ErrShortTags = errors.New("short-tag onix messages are not supported, convert the message to reference tags")
+
+// code list values of ONIX 3.0 that are used by the mapping
+const (
+	productIDProprietary = "01"
+	productIDISBN13      = "15"
+	productIDGTIN13      = "03"
+	nameIDProprietary    = "01"
+	contributorByAuthor  = "A01"
+	extentMainContent    = "00"
+	extentPages          = "03"
+	availabilityInStock  = "21"
+	availabilityNoStock  = "31"
+	priceRRPIncludingTax = "02"
+	stockIDTypeName      = "Stock ID"
+	notificationConfirm  = "03"
+	notificationDelete   = "05"
+	productFormBook      = "BA"
+	titleDistinctive     = "01"
+	titleLevelProduct    = "01"
+	supplierPublisher    = "01"
+)
+
+// Product: the parts of an ONIX product record that are mapped to a book
+type Product struct {
+	XMLName           xml.Name            `xml:"Product"`
+	RecordReference   string              `xml:"RecordReference"`
+	NotificationType  string              `xml:"NotificationType"`
+	ProductIdentifier []ProductIdentifier `xml:"ProductIdentifier"`
+	DescriptiveDetail DescriptiveDetail   `xml:"DescriptiveDetail"`
+	ProductSupply     *ProductSupply      `xml:"ProductSupply,omitempty"`
+}
+
+type ProductIdentifier struct {
+	ProductIDType string `xml:"ProductIDType"`
+	IDTypeName    string `xml:"IDTypeName,omitempty"`
+	IDValue       string `xml:"IDValue"`
+}
+
+type DescriptiveDetail struct {
+	ProductComposition string        `xml:"ProductComposition"`
+	ProductForm        string        `xml:"ProductForm"`
+	TitleDetail        []TitleDetail `xml:"TitleDetail"`
+	Contributor        []Contributor `xml:"Contributor"`
+	Extent             []Extent      `xml:"Extent"`
+}
+
+type TitleDetail struct {
+	TitleType    string         `xml:"TitleType"`
+	TitleElement []TitleElement `xml:"TitleElement"`
+}
+
+type TitleElement struct {
+	TitleElementLevel  string `xml:"TitleElementLevel"`
+	TitlePrefix        string `xml:"TitlePrefix,omitempty"`
+	TitleWithoutPrefix string `xml:"TitleWithoutPrefix,omitempty"`
+	TitleText          string `xml:"TitleText,omitempty"`
+}
+
+type Contributor struct {
+	SequenceNumber  int              `xml:"SequenceNumber,omitempty"`
+	ContributorRole []string         `xml:"ContributorRole"`
+	NameIdentifier  []NameIdentifier `xml:"NameIdentifier"`
+	PersonName      string           `xml:"PersonName,omitempty"`
+	CorporateName   string           `xml:"CorporateName,omitempty"`
+}
+
+type NameIdentifier struct {
+	NameIDType string `xml:"NameIDType"`
+	IDTypeName string `xml:"IDTypeName,omitempty"`
+	IDValue    string `xml:"IDValue"`
+}
+
+type Extent struct {
+	ExtentType  string `xml:"ExtentType"`
+	ExtentValue string `xml:"ExtentValue"`
+	ExtentUnit  string `xml:"ExtentUnit"`
+}
+
+type ProductSupply struct {
+	SupplyDetail []SupplyDetail `xml:"SupplyDetail"`
+}
+
+type SupplyDetail struct {
+	Supplier            Supplier `xml:"Supplier"`
+	ProductAvailability string   `xml:"ProductAvailability"`
+	Stock               []Stock  `xml:"Stock"`
+	Price               []Price  `xml:"Price"`
+}
+
+type Supplier struct {
+	SupplierRole string `xml:"SupplierRole"`
+	SupplierName string `xml:"SupplierName,omitempty"`
+}
+
+type Stock struct {
+	OnHand string `xml:"OnHand"`
+}
+
+type Price struct {
+	PriceType    string `xml:"PriceType,omitempty"`
+	PriceAmount  string `xml:"PriceAmount"`
+	CurrencyCode string `xml:"CurrencyCode,omitempty"`
+}
+
+// Decode: reads the products of an ONIX message one by one and passes the mapped book to the given function
+// products without a record reference or contributor cannot be mapped and are skipped, their number is returned
+// a message that is not an ONIX 3.0 message with reference tags is an error
+func Decode(r io.Reader, fn func(entities.Book) error) (int, error) {
+	skipped := 0
+	root := false
+	d := xml.NewDecoder(r)
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			if !root {
+				return skipped, fmt.Errorf("not an onix message: the file has no root element")
+			}
+			return skipped, nil
+		}
+		if err != nil {
+			return skipped, fmt.Errorf("cannot read onix message: %v", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !root {
+			if err := checkMessage(start); err != nil {
+				return skipped, err
+			}
+			root = true
+			continue
+		}
+		if start.Name.Local != "Product" {
+			continue
+		}
+		product := Product{}
+		if err := d.DecodeElement(&product, &start); err != nil {
+			return skipped, fmt.Errorf("cannot read onix product: %v", err)
+		}
+		book, err := product.ToBook()
+		if err != nil {
+			skipped++
+			continue
+		}
+		if err := fn(book); err != nil {
+			return skipped, err
+		}
+	}
+}
+
+// checkMessage: the root element must be the ONIXMessage of release 3.0 with the reference tags
+func checkMessage(root xml.StartElement) error {
+	switch {
+	case root.Name.Local == "ONIXmessage":
+		return ErrShortTags
+	case root.Name.Local != "ONIXMessage":
+		return fmt.Errorf("not an onix message: the root element is <%s>", root.Name.Local)
+	case root.Name.Space != "" && root.Name.Space != Namespace:
+		return fmt.Errorf("onix namespace %q is not supported, only %q", root.Name.Space, Namespace)
+	}
+	for _, attr := range root.Attr {
+		if attr.Name.Local == "release" && !strings.HasPrefix(attr.Value, "3.") {
+			return fmt.Errorf("onix release %s is not supported, only 3.0", attr.Value)
+		}
+	}
+	return nil
+}
+
+// ToBook: maps the product record to a book, the first author of the product becomes the author of the book
+func (p Product) ToBook() (entities.Book, error) {
+	book := entities.Book{ID: p.RecordReference}
+	for _, id := range p.ProductIdentifier {
+		switch {
+		case id.ProductIDType == productIDISBN13 || (id.ProductIDType == productIDGTIN13 && book.ISBN == ""):
+			book.ISBN = id.IDValue
+		case id.ProductIDType == productIDProprietary && id.IDTypeName == stockIDTypeName:
+			book.StockID = id.IDValue
+		}
+	}
+	book.Name = p.DescriptiveDetail.title()
+	// a deleted record carries no deletion time, the book is deleted as of the import
+	if p.NotificationType == notificationDelete {
+		book.DeletedAt = gorm.DeletedAt{Time: time.Now().UTC(), Valid: true}
+	}
+
+	author := p.DescriptiveDetail.author()
+	if book.ID == "" || author == nil {
+		return entities.Book{}, fmt.Errorf("onix product %q cannot be mapped to a book", p.RecordReference)
+	}
+	book.Author = author
+	book.AuthorID = author.ID
+
+	for _, e := range p.DescriptiveDetail.Extent {
+		if e.ExtentType == extentMainContent && e.ExtentUnit == extentPages {
+			if pages, err := strconv.Atoi(e.ExtentValue); err == nil {
+				book.PageNumber = uint(pages)
+			}
+		}
+	}
+
+	if p.ProductSupply != nil && len(p.ProductSupply.SupplyDetail) > 0 {
+		supply := p.ProductSupply.SupplyDetail[0]
+		for _, s := range supply.Stock {
+			if onHand, err := strconv.Atoi(s.OnHand); err == nil {
+				book.StockNumber += onHand
+			}
+		}
+		// a product that is not available (any availability code but 2x) cannot be ordered whatever is on hand
+		if !available(supply.ProductAvailability) {
+			book.StockNumber = 0
+		}
+		if len(supply.Price) > 0 {
+			if price, err := strconv.ParseFloat(supply.Price[0].PriceAmount, 32); err == nil {
+				book.Price = float32(price)
+			}
+		}
+	}
+	return book, nil
+}
+
+// available: the availability codes 20 to 29 (e.g. 21 in stock, 22 to order) or no code, the others are not yet,
+// temporarily or no longer available (e.g. 10 not yet available, 31 out of stock, 40 not available, 46 withdrawn from sale)
+func available(code string) bool {
+	return code == "" || (len(code) == 2 && code[0] == '2')
+}
+
+// title: returns the distinctive title of the product
+func (d DescriptiveDetail) title() string {
+	for _, t := range d.TitleDetail {
+		if t.TitleType != titleDistinctive {
+			continue
+		}
+		for _, e := range t.TitleElement {
+			if e.TitleText != "" {
+				return e.TitleText
+			}
+			return strings.TrimSpace(e.TitlePrefix + " " + e.TitleWithoutPrefix)
+		}
+	}
+	return ""
+}
+
+// author: returns the first contributor having the author role, the first contributor is used if there is none
+func (d DescriptiveDetail) author() *entities.Author {
+	var chosen *Contributor
+	for i, c := range d.Contributor {
+		for _, role := range c.ContributorRole {
+			if role == contributorByAuthor {
+				chosen = &d.Contributor[i]
+				break
+			}
+		}
+		if chosen != nil {
+			break
+		}
+	}
+	if chosen == nil && len(d.Contributor) > 0 {
+		chosen = &d.Contributor[0]
+	}
+	if chosen == nil {
+		return nil
+	}
+
+	author := entities.Author{Name: chosen.PersonName}
+	if author.Name == "" {
+		author.Name = chosen.CorporateName
+	}
+	for _, id := range chosen.NameIdentifier {
+		if id.NameIDType == nameIDProprietary {
+			author.ID = id.IDValue
+		}
+	}
+	if author.ID == "" {
+		return nil
+	}
+	return &author
+}
+
+// FromBook: maps a book with its author to an ONIX product record
+func FromBook(book entities.Book) Product {
+	p := Product{
+		RecordReference:  book.ID,
+		NotificationType: notificationConfirm,
+		ProductIdentifier: []ProductIdentifier{
+			{ProductIDType: productIDProprietary, IDTypeName: stockIDTypeName, IDValue: book.StockID},
+		},
+		DescriptiveDetail: DescriptiveDetail{
+			ProductComposition: "00",
+			ProductForm:        productFormBook,
+			TitleDetail: []TitleDetail{{
+				TitleType:    titleDistinctive,
+				TitleElement: []TitleElement{{TitleElementLevel: titleLevelProduct, TitleText: book.Name}},
+			}},
+			Extent: []Extent{{ExtentType: extentMainContent, ExtentValue: strconv.Itoa(int(book.PageNumber)), ExtentUnit: extentPages}},
+		},
+	}
+	if book.DeletedAt.Valid {
+		p.NotificationType = notificationDelete
+	}
+	if book.ISBN != "" {
+		p.ProductIdentifier = append(p.ProductIdentifier, ProductIdentifier{ProductIDType: productIDISBN13, IDValue: book.ISBN})
+	}
+	if book.Author != nil {
+		p.DescriptiveDetail.Contributor = []Contributor{{
+			SequenceNumber:  1,
+			ContributorRole: []string{contributorByAuthor},
+			NameIdentifier:  []NameIdentifier{{NameIDType: nameIDProprietary, IDValue: book.Author.ID}},
+			PersonName:      book.Author.Name,
+		}}
+	}
+
+	availability := availabilityInStock
+	if book.StockNumber <= 0 {
+		availability = availabilityNoStock
+	}
+	p.ProductSupply = &ProductSupply{SupplyDetail: []SupplyDetail{{
+		Supplier:            Supplier{SupplierRole: supplierPublisher},
+		ProductAvailability: availability,
+		Stock:               []Stock{{OnHand: strconv.Itoa(book.StockNumber)}},
+		Price:               []Price{{PriceType: priceRRPIncludingTax, PriceAmount: strconv.FormatFloat(float64(book.Price), 'f', 2, 32)}},
+	}}}
+	return p
+}
+
+// Encoder: writes books as the products of a single ONIX message
+type Encoder struct {
+	enc *xml.Encoder
+}
+
+// NewEncoder: writes the header of an ONIX message with the given sender name
+func NewEncoder(w io.Writer, sender string) (*Encoder, error) {
+	e := &Encoder{enc: xml.NewEncoder(w)}
+	e.enc.Indent("", "  ")
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+	message := xml.StartElement{
+		Name: xml.Name{Local: "ONIXMessage"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: Namespace}, {Name: xml.Name{Local: "release"}, Value: "3.0"}},
+	}
+	if err := e.enc.EncodeToken(message); err != nil {
+		return nil, err
+	}
+	header := struct {
+		XMLName      xml.Name `xml:"Header"`
+		SenderName   string   `xml:"Sender>SenderName"`
+		SentDateTime string   `xml:"SentDateTime"`
+	}{SenderName: sender, SentDateTime: time.Now().UTC().Format("20060102T1504Z")}
+	if err := e.enc.Encode(header); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Encode: writes the book as a product of the message
+func (e *Encoder) Encode(book entities.Book) error {
+	return e.enc.Encode(FromBook(book))
+}
+
+// Close: closes the ONIX message
+func (e *Encoder) Close() error {
+	if err := e.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "ONIXMessage"}}); err != nil {
+		return err
+	}
+	return e.enc.Flush()
+}
@@ -0,0 +1,167 @@
+package onix
+
+import (
+	"bookApp/internal/domain/entities"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TestDecode: reads the sample messages of testdata, written with the reference tags of ONIX 3.0
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []entities.Book
+		deleted []bool
+		skipped int
+		err     string
+	}{
+		{
+			name: "reference tags",
+			file: "reference.xml",
+			want: []entities.Book{
+				{ID: "1", Name: "A Tale of Two Cities", PageNumber: 320, StockNumber: 10, StockID: "21AC", Price: 15.3, ISBN: "9780451530578", AuthorID: "101"},
+				// out of stock (31): the copies on hand cannot be ordered, the author is the A01 contributor
+				{ID: "2", Name: "The Hobbit", PageNumber: 376, StockNumber: 0, StockID: "44UY", Price: 24, ISBN: "9780547928227", AuthorID: "202"},
+			},
+			deleted: []bool{false, false},
+			// the product without a contributor
+			skipped: 1,
+		},
+		{
+			name:    "delete notification",
+			file:    "delete.xml",
+			want:    []entities.Book{{ID: "1", Name: "A Tale of Two Cities", StockID: "21AC", AuthorID: "101"}},
+			deleted: []bool{true},
+		},
+		{
+			name: "short tags",
+			file: "short.xml",
+			err:  ErrShortTags.Error(),
+		},
+		{
+			name: "release 2.1",
+			file: "release21.xml",
+			err:  `onix namespace "http://www.editeur.org/onix/2.1/reference" is not supported`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			books := []entities.Book{}
+			skipped, err := Decode(f, func(book entities.Book) error {
+				books = append(books, book)
+				return nil
+			})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skipped != tt.skipped {
+				t.Errorf("skipped %d products, want %d", skipped, tt.skipped)
+			}
+			if len(books) != len(tt.want) {
+				t.Fatalf("read %d books, want %d", len(books), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := books[i]
+				if got.Author == nil || got.Author.ID != got.AuthorID {
+					t.Errorf("book %s: author %+v does not match the author id %s", got.ID, got.Author, got.AuthorID)
+				}
+				if got.DeletedAt.Valid != tt.deleted[i] {
+					t.Errorf("book %s deleted at %+v, want deleted %v", got.ID, got.DeletedAt, tt.deleted[i])
+				}
+				got.Author = nil
+				got.DeletedAt = gorm.DeletedAt{}
+				if got != want {
+					t.Errorf("book %d:\n got %+v\nwant %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestDecodeNotONIX: a message of another format is an error instead of an import of no books
+func TestDecodeNotONIX(t *testing.T) {
+	for _, message := range []string{"", `<?xml version="1.0"?><catalog><Product/></catalog>`, `<ONIXmessage><product/></ONIXmessage>`} {
+		_, err := Decode(strings.NewReader(message), func(entities.Book) error { return nil })
+		if err == nil {
+			t.Errorf("no error for %q", message)
+		}
+	}
+	_, err := Decode(strings.NewReader(`<ONIXmessage release="3.0"/>`), func(entities.Book) error { return nil })
+	if !errors.Is(err, ErrShortTags) {
+		t.Errorf("error %v, want %v", err, ErrShortTags)
+	}
+}
+
+// TestRoundTrip: the books written by the encoder are read back by Decode, a deleted book as a delete notification
+func TestRoundTrip(t *testing.T) {
+	books := []entities.Book{
+		{ID: "1", Name: "A Tale of Two Cities", PageNumber: 320, StockNumber: 10, StockID: "21AC", Price: 15.3, ISBN: "9780451530578",
+			AuthorID: "101", Author: &entities.Author{ID: "101", Name: "Charles Dickens"}},
+		{ID: "2", Name: "The Hobbit", PageNumber: 376, StockNumber: 0, StockID: "44UY", Price: 24, ISBN: "9780547928227",
+			AuthorID: "202", Author: &entities.Author{ID: "202", Name: "J. R. R. Tolkien"}},
+		{ID: "3", Name: "Emma", PageNumber: 474, StockNumber: 2, StockID: "7JA", Price: 9.5,
+			AuthorID: "303", Author: &entities.Author{ID: "303", Name: "Jane Austen"},
+			Model: gorm.Model{DeletedAt: gorm.DeletedAt{Time: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), Valid: true}}},
+	}
+
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, "bookApp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, book := range books {
+		if err := enc.Encode(book); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := []entities.Book{}
+	skipped, err := Decode(&buf, func(book entities.Book) error {
+		decoded = append(decoded, book)
+		return nil
+	})
+	if err != nil || skipped != 0 {
+		t.Fatalf("skipped %d products, error %v", skipped, err)
+	}
+	if len(decoded) != len(books) {
+		t.Fatalf("read %d books, want %d", len(decoded), len(books))
+	}
+	for i, want := range books {
+		got := decoded[i]
+		if got.Author == nil || got.Author.ID != want.Author.ID || got.Author.Name != want.Author.Name {
+			t.Errorf("book %s: author %+v, want %+v", want.ID, got.Author, want.Author)
+		}
+		if got.DeletedAt.Valid != want.DeletedAt.Valid {
+			t.Errorf("book %s deleted at %+v, want %+v", want.ID, got.DeletedAt, want.DeletedAt)
+		}
+		got.Author, want.Author = nil, nil
+		got.DeletedAt, want.DeletedAt = gorm.DeletedAt{}, gorm.DeletedAt{}
+		if got != want {
+			t.Errorf("book %d:\n got %+v\nwant %+v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package repos
+
+import (
+	"bookApp/internal/auth"
+	"bookApp/internal/domain/entities"
+	"bookApp/internal/tracing"
+	"bookApp/pkg/requestid"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// systemActor: the actor of the changes made without a principal, e.g. the initial import of serve
+const systemActor = "system"
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// FindByEntity: returns the audit trail of the book or author with the given ID, oldest entry first
+func (a *AuditRepository) FindByEntity(ctx context.Context, entity, id string) ([]entities.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "AuditRepository.FindByEntity")
+	defer span.End()
+	entries := []entities.AuditEntry{}
+	// a struct condition would drop an empty id and return the entries of every entity
+	result := a.db.WithContext(ctx).Where("entity = ? AND entity_id = ?", entity, id).Order("created_at, id").Find(&entries)
+	if result.Error != nil {
+		return nil, queryError(ctx, result.Error)
+	}
+	return entries, nil
+}
+
+// auditEntry: creates the entry of a change made by the caller of the context of the transaction
+// before and after are the audit fields of the entity, nil if it did not exist before or does not exist after the change
+func auditEntry(tx *gorm.DB, action entities.AuditAction, entity, id string, before, after map[string]interface{}) entities.AuditEntry {
+	ctx := tx.Statement.Context
+	entry := entities.AuditEntry{
+		Action:    action,
+		Entity:    entity,
+		EntityID:  id,
+		Actor:     systemActor,
+		RequestID: requestid.FromContext(ctx),
+		Changes:   entities.Diff(before, after),
+	}
+	if principal := auth.FromContext(ctx); principal != nil {
+		entry.Actor = principal.Subject
+		entry.AuthMethod = principal.Method
+	}
+	return entry
+}
+
+// recordAudit: writes the entries in the transaction of the changes, so a change is never made without its entry
+func recordAudit(tx *gorm.DB, entries ...entities.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&entries, insertBatchSize).Error
+}
@@ -2,7 +2,8 @@ package repos
 
 import (
 	"bookApp/internal/domain/entities"
-	"fmt"
+	"bookApp/internal/tracing"
+	"context"
 
 	"gorm.io/gorm"
 )
@@ -15,83 +16,85 @@ func NewAuthorRepository(db *gorm.DB) *AuthorRepository {
 	return &AuthorRepository{db: db}
 }
 
-// SetupDatabase: automatically migrates database of Authors with gorm and insert author data to database by the given input path
-func (a *AuthorRepository) SetupDatabase(path string) {
-	a.Migrations()
-	a.InsertAuthorData(path)
+// SetupDatabase: insert author data to database by the given input path, the schema must be migrated before
+// the columns of the file are mapped with the given csv profile
+func (a *AuthorRepository) SetupDatabase(ctx context.Context, path string, profile CSVProfile) error {
+	return a.InsertAuthorData(ctx, path, profile)
 }
 
-// Migrations: automatically migrates database of Authors
-func (a *AuthorRepository) Migrations() {
-	a.db.AutoMigrate(&entities.Author{})
-}
-
-// InsertAuthorData: insert author data to database by the given input path
-func (a *AuthorRepository) InsertAuthorData(path string) error {
+// InsertAuthorData: insert author data to database by the given input path (csv or ONIX)
+// the columns of the file are mapped with the given csv profile
+// authors are de-duplicated and written in batches, the ones already in the database are skipped
+func (a *AuthorRepository) InsertAuthorData(ctx context.Context, path string, profile CSVProfile) error {
 
-	_, authors, err := readDataWithWorkerPool(path)
+	ctx, span := tracing.Start(ctx, "AuthorRepository.InsertAuthorData")
+	defer span.End()
+	_, authors, _, err := readData(path, profile)
 	if err != nil {
 		return err
 	}
-	for _, author := range authors {
-		a.db.Where(entities.Author{ID: author.ID}).Attrs(entities.Author{ID: author.ID, Name: author.Name}).FirstOrCreate(&author)
-	}
-	return nil
+	return queryError(ctx, insertAuthors(a.db.WithContext(ctx), authors, entities.AuditImport))
 }
 
 // FindAuthorsWithBookInfo: Find all the authors with their book data
-func (a *AuthorRepository) FindAuthorsWithBookInfo() ([]entities.Author, error) {
+func (a *AuthorRepository) FindAuthorsWithBookInfo(ctx context.Context) ([]entities.Author, error) {
+	ctx, span := tracing.Start(ctx, "AuthorRepository.FindAuthorsWithBookInfo")
+	defer span.End()
 	authors := []entities.Author{}
-	result := a.db.Preload("Books").Find(&authors)
+	result := a.db.WithContext(ctx).Preload("Books").Find(&authors)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return authors, nil
 }
 
 // FindAuthorsWithBookInfo: Find all the authors without their book data
-func (a *AuthorRepository) FindAuthorsWithoutBookInfo() ([]entities.Author, error) {
+func (a *AuthorRepository) FindAuthorsWithoutBookInfo(ctx context.Context) ([]entities.Author, error) {
+	ctx, span := tracing.Start(ctx, "AuthorRepository.FindAuthorsWithoutBookInfo")
+	defer span.End()
 	authors := []entities.Author{}
-	result := a.db.Find(&authors)
+	result := a.db.WithContext(ctx).Find(&authors)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return authors, nil
 }
 
 // FindByAuthorID: returns the author with given ID input
 // the search is elastic and case insensitive
-func (a *AuthorRepository) FindByAuthorID(ID string) (*entities.Author, error) {
+func (a *AuthorRepository) FindByAuthorID(ctx context.Context, ID string) (*entities.Author, error) {
+	ctx, span := tracing.Start(ctx, "AuthorRepository.FindByAuthorID")
+	defer span.End()
 	author := entities.Author{}
-	result := a.db.Preload("Books").Where(&entities.Author{ID: ID}).First(&author)
+	result := a.db.WithContext(ctx).Preload("Books").Where(&entities.Author{ID: ID}).First(&author)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return &author, nil
 }
 
 // FindByAuthorName: returns the author with given name input
 // the search is elastic and case insensitive
-func (a *AuthorRepository) FindByAuthorName(name string) ([]entities.Author, error) {
+func (a *AuthorRepository) FindByAuthorName(ctx context.Context, name string) ([]entities.Author, error) {
+	ctx, span := tracing.Start(ctx, "AuthorRepository.FindByAuthorName")
+	defer span.End()
 	authors := []entities.Author{}
-	nameString := fmt.Sprintf("%%%s%%", name)
-
-	result := a.db.Where("name ILIKE ?", nameString).Find(&authors)
+	result := whereContains(a.db.WithContext(ctx), "name", name).Find(&authors)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return authors, nil
 }
 
 // FindBooksOfAuthorByName: returns the author with given name input as well as his/her books
 // the search is elastic and case insensitive
-func (a *AuthorRepository) FindBooksOfAuthorByName(name string) ([]entities.Author, error) {
+func (a *AuthorRepository) FindBooksOfAuthorByName(ctx context.Context, name string) ([]entities.Author, error) {
+	ctx, span := tracing.Start(ctx, "AuthorRepository.FindBooksOfAuthorByName")
+	defer span.End()
 	authors := []entities.Author{}
-	nameString := fmt.Sprintf("%%%s%%", name)
-
-	result := a.db.Preload("Books").Where("name ILIKE ?", nameString).Find(&authors)
+	result := whereContains(a.db.WithContext(ctx).Preload("Books"), "name", name).Find(&authors)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return authors, nil
 }
@@ -2,11 +2,35 @@ package repos
 
 import (
 	"bookApp/internal/domain/entities"
+	"bookApp/internal/metrics"
+	"bookApp/internal/tracing"
+	"bookApp/pkg/logger"
+	"context"
+	"errors"
 	"fmt"
 
 	"gorm.io/gorm"
 )
 
+// ErrBookExists: returned when a book is created with the ID of an existing book
+var ErrBookExists = errors.New("book already exists")
+
+// ErrBookNotDeleted: returned when a book is purged before it is soft deleted
+var ErrBookNotDeleted = errors.New("book is not deleted")
+
+// ErrInvalidQuantity: returned when less than one book is ordered
+var ErrInvalidQuantity = errors.New("quantity must be at least 1")
+
+// StockError: returned when a book is ordered more than its stock
+type StockError struct {
+	Name  string
+	Stock int
+}
+
+func (e *StockError) Error() string {
+	return fmt.Sprintf("Not enough stock for %s, please order less than %d book/s.", e.Name, e.Stock)
+}
+
 type BookRepository struct {
 	db *gorm.DB
 }
@@ -15,148 +39,299 @@ func NewBookRepository(db *gorm.DB) *BookRepository {
 	return &BookRepository{db: db}
 }
 
-// SetupDatabase: automatically migrates database of Books with gorm and insert book data to database by the given input path
-func (b *BookRepository) SetupDatabase(path string) {
-	b.Migrations()
-	b.InsertBookData(path)
+// SetupDatabase: insert book data to database by the given input path, the schema must be migrated before
+// the columns of the file are mapped with the given csv profile
+func (b *BookRepository) SetupDatabase(ctx context.Context, path string, profile CSVProfile) error {
+	return b.InsertBookData(ctx, path, profile)
 }
 
-// Migrations: automatically migrates database of Books
-func (b *BookRepository) Migrations() {
-	b.db.AutoMigrate(&entities.Book{})
+// InsertBookData: insert book data to database by the given input path (csv or ONIX)
+// the columns of the file are mapped with the given csv profile
+// books and their authors are written in batches, the ones already in the database are skipped
+func (b *BookRepository) InsertBookData(ctx context.Context, path string, profile CSVProfile) error {
+	ctx, span := tracing.Start(ctx, "BookRepository.InsertBookData")
+	defer span.End()
+	books, authors, skipped, err := readData(path, profile)
+	if err != nil {
+		return err
+	}
+	if _, err := insertBooks(b.db.WithContext(ctx), books, entities.AuditImport); err != nil {
+		return queryError(ctx, err)
+	}
+	format := importFormat(path)
+	metrics.ImportRows.WithLabelValues(format, "imported").Add(float64(len(books)))
+	metrics.ImportRows.WithLabelValues(format, "skipped").Add(float64(skipped))
+	logger.FromContext(ctx).Info("book data is imported", "file", path, "profile", profile.Name, "books", len(books), "authors", len(uniqueAuthors(authors)), "skipped", skipped)
+	return nil
 }
 
-// InsertBookData: insert book data to database by the given input path
-func (b *BookRepository) InsertBookData(path string) error {
-	books, _, err := readDataWithWorkerPool(path)
+// UpdateBookData: imports the book data of the file like InsertBookData but overwrites the books that exist already
+// with the rows of the file, including their deletion time, so an export with the deleted books restores the state it was taken of
+func (b *BookRepository) UpdateBookData(ctx context.Context, path string, profile CSVProfile) error {
+	ctx, span := tracing.Start(ctx, "BookRepository.UpdateBookData")
+	defer span.End()
+	books, authors, skipped, err := readData(path, profile)
 	if err != nil {
 		return err
 	}
-	for _, book := range books {
-		err := b.AddBook(book)
-		if err != nil {
-			continue
-		}
+	created, changed, err := updateBooks(b.db.WithContext(ctx), books, entities.AuditImport)
+	if err != nil {
+		return queryError(ctx, err)
 	}
+	format := importFormat(path)
+	metrics.ImportRows.WithLabelValues(format, "imported").Add(float64(len(books)))
+	metrics.ImportRows.WithLabelValues(format, "skipped").Add(float64(skipped))
+	logger.FromContext(ctx).Info("book data is imported", "file", path, "profile", profile.Name, "books", len(books), "created", created, "changed", changed,
+		"authors", len(uniqueAuthors(authors)), "skipped", skipped)
 	return nil
 }
 
 // AddBook: Given a book struct create data in database (if not exist already)
-func (b *BookRepository) AddBook(book entities.Book) error {
+// the author of the book is created as well if it does not exist
+func (b *BookRepository) AddBook(ctx context.Context, book entities.Book) error {
+	ctx, span := tracing.Start(ctx, "BookRepository.AddBook")
+	defer span.End()
+	_, err := insertBooks(b.db.WithContext(ctx), []entities.Book{book}, entities.AuditCreate)
+	return queryError(ctx, err)
+}
 
-	result := b.db.Unscoped().Where(entities.Book{ID: book.ID}).Attrs(entities.Book{ID: book.ID, Name: book.Name, PageNumber: book.PageNumber, StockNumber: book.StockNumber, StockID: book.StockID, Price: book.Price, ISBN: book.ISBN, Author: &entities.Author{ID: book.Author.ID, Name: book.Author.Name}}).FirstOrCreate(&book)
-	if result.Error != nil {
-		return result.Error
+// CreateBook: creates the book and its author if the author does not exist
+// unlike AddBook it returns ErrBookExists if there is already a book (including a soft deleted one) with the ID
+func (b *BookRepository) CreateBook(ctx context.Context, book entities.Book) error {
+	ctx, span := tracing.Start(ctx, "BookRepository.CreateBook")
+	defer span.End()
+	created, err := insertBooks(b.db.WithContext(ctx), []entities.Book{book}, entities.AuditCreate)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	if created == 0 {
+		return fmt.Errorf("%w: %s", ErrBookExists, book.ID)
 	}
-
 	return nil
 }
 
 // FindAll(): return all the books in database
-func (b *BookRepository) FindAll() ([]entities.Book, error) {
+func (b *BookRepository) FindAll(ctx context.Context) ([]entities.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookRepository.FindAll")
+	defer span.End()
 	books := []entities.Book{}
-	result := b.db.Preload("Author").Find(&books)
+	result := b.db.WithContext(ctx).Preload("Author").Find(&books)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return books, nil
 }
 
 // FindByBookID: returns the book with given ID input
-func (b *BookRepository) FindByBookID(ID string) (*entities.Book, error) {
+func (b *BookRepository) FindByBookID(ctx context.Context, ID string) (*entities.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookRepository.FindByBookID")
+	defer span.End()
 	book := entities.Book{}
-	result := b.db.First(&book, ID)
+	// the ID is a string, as an inline condition a non numeric ID would be taken as sql
+	result := b.db.WithContext(ctx).Where("id = ?", ID).First(&book)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return &book, nil
 }
 
 // FindByBookISBN: returns the book with given ISBN input
-func (b *BookRepository) FindByBookISBN(ISBN string) (*entities.Book, error) {
+func (b *BookRepository) FindByBookISBN(ctx context.Context, ISBN string) (*entities.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookRepository.FindByBookISBN")
+	defer span.End()
 	book := entities.Book{}
-	result := b.db.Where(&entities.Book{ISBN: ISBN}).Find(&book)
+	// First, so an unknown ISBN is not found instead of an empty book
+	result := b.db.WithContext(ctx).Where("isbn = ?", ISBN).First(&book)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return &book, nil
 }
 
 // FindByBookName: returns the book/s with given name input
 // the search is elastic and case insensitive
-func (b *BookRepository) FindByBookName(name string) ([]entities.Book, error) {
+func (b *BookRepository) FindByBookName(ctx context.Context, name string) ([]entities.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookRepository.FindByBookName")
+	defer span.End()
 	books := []entities.Book{}
-	nameString := fmt.Sprintf("%%%s%%", name)
-	result := b.db.Where("name ILIKE ?", nameString).Find(&books)
+	result := whereContains(b.db.WithContext(ctx), "name", name).Find(&books)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return books, nil
 }
 
-// DeleteByBookID: soft deletes book from the database
-func (b *BookRepository) DeleteByBookID(id string) error {
+// DeleteByBookID: soft deletes book from the database and records the deletion in the audit trail
+func (b *BookRepository) DeleteByBookID(ctx context.Context, id string) error {
 
-	book, err := b.FindByBookID(id)
+	ctx, span := tracing.Start(ctx, "BookRepository.DeleteByBookID")
+	defer span.End()
+	book, err := b.FindByBookID(ctx, id)
 	if err != nil {
 		return err
 	}
-	result := b.db.Delete(&book)
-
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&book).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditEntry(tx, entities.AuditDelete, entities.AuditEntityBook, book.ID, book.AuditFields(), nil))
+	})
+	return queryError(ctx, err)
 }
 
 // BuyByBookID: orders books that is in the database (not soft deleted) with given id input and requested quantity only if there is enough stock for the order.
-func (b *BookRepository) BuyByBookID(id string, num int) error {
+// the change of the stock is recorded in the audit trail
+func (b *BookRepository) BuyByBookID(ctx context.Context, id string, num int) error {
 
-	book, err := b.FindByBookID(id)
+	ctx, span := tracing.Start(ctx, "BookRepository.BuyByBookID")
+	defer span.End()
+	// a negative order would add to the stock and cannot be counted as sold
+	if num < 1 {
+		return ErrInvalidQuantity
+	}
+	book, err := b.FindByBookID(ctx, id)
 	if err != nil {
 		return err
 	}
 	if book.StockNumber >= num {
-		b.db.Model(&book).Update("stock_number", book.StockNumber-num)
-		book.AfterOrder(num)
+		before := book.AuditFields()
+		err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&book).Update("stock_number", book.StockNumber-num).Error; err != nil {
+				return err
+			}
+			return recordAudit(tx, auditEntry(tx, entities.AuditPurchase, entities.AuditEntityBook, book.ID, before, book.AuditFields()))
+		})
+		if err != nil {
+			return queryError(ctx, err)
+		}
+		book.AfterOrder(ctx, num)
+		metrics.BooksOrdered.Add(float64(num))
+		metrics.AuthorUnitsSold.WithLabelValues(book.AuthorID).Add(float64(num))
 	} else {
-		return fmt.Errorf("Not enough stock for %s, please order less than %d book/s.", book.Name, book.StockNumber)
+		metrics.OutOfStockRejections.Inc()
+		return &StockError{Name: book.Name, Stock: book.StockNumber}
 	}
 
 	return nil
 }
 
+// SetStock: sets the stock number of the book (not soft deleted) with given id input to the counted stock
+// the change of the stock is recorded in the audit trail, the book is returned with the new stock
+func (b *BookRepository) SetStock(ctx context.Context, id string, stock int) (*entities.Book, error) {
+
+	ctx, span := tracing.Start(ctx, "BookRepository.SetStock")
+	defer span.End()
+	book, err := b.FindByBookID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := book.AuditFields()
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&book).Update("stock_number", stock).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditEntry(tx, entities.AuditUpdate, entities.AuditEntityBook, book.ID, before, book.AuditFields()))
+	})
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return book, nil
+}
+
+// PurgeByBookID: permanently removes a soft deleted book from the database and records the purge in the audit trail
+// a book that is not soft deleted must be deleted first, ErrBookNotDeleted is returned for it
+func (b *BookRepository) PurgeByBookID(ctx context.Context, id string) error {
+
+	ctx, span := tracing.Start(ctx, "BookRepository.PurgeByBookID")
+	defer span.End()
+	book := entities.Book{}
+	if err := b.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&book).Error; err != nil {
+		return queryError(ctx, err)
+	}
+	if !book.DeletedAt.Valid {
+		return fmt.Errorf("%w: %s", ErrBookNotDeleted, book.ID)
+	}
+	err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&book).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditEntry(tx, entities.AuditPurge, entities.AuditEntityBook, book.ID, book.AuditFields(), nil))
+	})
+	return queryError(ctx, err)
+}
+
+// RestoreByBookID: restores a soft deleted book and records the restore in the audit trail, the book is returned
+// a book that is not soft deleted cannot be restored, ErrBookNotDeleted is returned for it
+func (b *BookRepository) RestoreByBookID(ctx context.Context, id string) (*entities.Book, error) {
+
+	ctx, span := tracing.Start(ctx, "BookRepository.RestoreByBookID")
+	defer span.End()
+	book := entities.Book{}
+	if err := b.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&book).Error; err != nil {
+		return nil, queryError(ctx, err)
+	}
+	if !book.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: %s", ErrBookNotDeleted, book.ID)
+	}
+	err := b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&book).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditEntry(tx, entities.AuditRestore, entities.AuditEntityBook, book.ID, nil, book.AuditFields()))
+	})
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	book.DeletedAt = gorm.DeletedAt{}
+	return &book, nil
+}
+
 //------------------Extra Queries------------------//
 // FindAllIncludingDeleted(): return all the books including the deleted ones in database
-func (b *BookRepository) FindAllIncludingDeleted() ([]entities.Book, error) {
+func (b *BookRepository) FindAllIncludingDeleted(ctx context.Context) ([]entities.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookRepository.FindAllIncludingDeleted")
+	defer span.End()
 	books := []entities.Book{}
-	result := b.db.Unscoped().Find(&books)
+	result := b.db.WithContext(ctx).Unscoped().Find(&books)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return books, nil
 }
 
 // FindAllInStock(): find all books that are currently in stock (stock number > 0).
 // Warning: this function is not for showing deleted books, it checks the stock numbers.
-func (b *BookRepository) FindAllInStock() ([]entities.Book, error) {
+func (b *BookRepository) FindAllInStock(ctx context.Context) ([]entities.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookRepository.FindAllInStock")
+	defer span.End()
 	books := []entities.Book{}
-	result := b.db.Where("stock_number > ?", 0).Find(&books)
+	result := b.db.WithContext(ctx).Where("stock_number > ?", 0).Find(&books)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
 	return books, nil
 }
 
-// FindAllUnderPrice(): find all books under a given price input and also that are currently in stock.
-func (b *BookRepository) FindAllBooksUnderPrice(price float32) ([]entities.Book, error) {
+// FindAllUnderPrice(): find all books under a given price input and also that are currently in stock, the result may be empty.
+func (b *BookRepository) FindAllBooksUnderPrice(ctx context.Context, price float32) ([]entities.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookRepository.FindAllBooksUnderPrice")
+	defer span.End()
 	books := []entities.Book{}
-	result := b.db.Where("stock_number > ?", 0).Where("price < ?", price).Find(&books)
+	result := b.db.WithContext(ctx).Where("stock_number > ?", 0).Where("price < ?", price).Find(&books)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, queryError(ctx, result.Error)
 	}
-	if len(books) == 0 {
-		return nil, fmt.Errorf("There is no books in the stock under %.2f", price)
+	return books, nil
+}
+
+// FindAllStockAtMost(): find all books of which the stock number is at most the given limit, lowest stock first.
+func (b *BookRepository) FindAllStockAtMost(ctx context.Context, limit int) ([]entities.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookRepository.FindAllStockAtMost")
+	defer span.End()
+	books := []entities.Book{}
+	result := b.db.WithContext(ctx).Where("stock_number <= ?", limit).Order("stock_number").Find(&books)
+	if result.Error != nil {
+		return nil, queryError(ctx, result.Error)
 	}
 	return books, nil
 }
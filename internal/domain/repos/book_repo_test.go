@@ -0,0 +1,50 @@
+package repos
+
+import (
+	"bookApp/internal/domain/entities"
+	"bookApp/internal/metrics"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestBuyByBookIDQuantity: an order of less than one book is rejected before the stock, the audit trail or the counters are changed
+func TestBuyByBookIDQuantity(t *testing.T) {
+	db := testDB(t)
+	repo := NewBookRepository(db)
+	ctx := context.Background()
+	book := entities.Book{ID: "1", Name: "A Tale of Two Cities", StockNumber: 10, StockID: "21AC", AuthorID: "101",
+		Author: &entities.Author{ID: "101", Name: "Charles Dickens"}}
+	if err := repo.AddBook(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, quantity := range []int{0, -5} {
+		ordered := testutil.ToFloat64(metrics.BooksOrdered)
+		if err := repo.BuyByBookID(ctx, "1", quantity); !errors.Is(err, ErrInvalidQuantity) {
+			t.Errorf("quantity %d: error %v, want %v", quantity, err, ErrInvalidQuantity)
+		}
+		stored, err := repo.FindByBookID(ctx, "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.StockNumber != 10 {
+			t.Errorf("quantity %d: stock %d, want 10", quantity, stored.StockNumber)
+		}
+		if got := testutil.ToFloat64(metrics.BooksOrdered); got != ordered {
+			t.Errorf("quantity %d: %v books counted as ordered, want %v", quantity, got, ordered)
+		}
+	}
+
+	entries, err := NewAuditRepository(db).FindByEntity(ctx, entities.AuditEntityBook, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Action == entities.AuditPurchase {
+			t.Errorf("purchase recorded: %+v", entry)
+		}
+	}
+}
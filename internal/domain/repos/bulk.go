@@ -0,0 +1,270 @@
+package repos
+
+import (
+	"bookApp/internal/domain/entities"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// insertBatchSize: number of rows written by a single insert statement
+const insertBatchSize = 1000
+
+// ErrStockIDExists: returned when a book is created with the stock ID of another book
+var ErrStockIDExists = errors.New("stock id already exists")
+
+// onConflictID: skips the rows whose ID exists already, a violation of another unique column (e.g. the stock ID) is an error
+var onConflictID = clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, DoNothing: true}
+
+// uniqueAuthors: removes the duplicate authors (same ID) keeping the first one, authors are emitted once per book by the readers
+func uniqueAuthors(authors []entities.Author) []entities.Author {
+	seen := make(map[string]struct{}, len(authors))
+	unique := make([]entities.Author, 0, len(authors))
+	for _, author := range authors {
+		if _, ok := seen[author.ID]; ok {
+			continue
+		}
+		seen[author.ID] = struct{}{}
+		unique = append(unique, entities.Author{ID: author.ID, Name: author.Name})
+	}
+	return unique
+}
+
+// uniqueBooks: removes the duplicate books (same ID) keeping the first one
+func uniqueBooks(books []entities.Book) []entities.Book {
+	seen := make(map[string]struct{}, len(books))
+	unique := make([]entities.Book, 0, len(books))
+	for _, book := range books {
+		if _, ok := seen[book.ID]; ok {
+			continue
+		}
+		seen[book.ID] = struct{}{}
+		unique = append(unique, book)
+	}
+	return unique
+}
+
+// insertAuthors: writes the authors in batches, authors that already exist (including soft deleted ones) are left as they are
+// an audit entry with the given action is recorded for every author that is created
+func insertAuthors(db *gorm.DB, authors []entities.Author, action entities.AuditAction) error {
+	authors = uniqueAuthors(authors)
+	if len(authors) == 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		ids := make([]string, len(authors))
+		for i, author := range authors {
+			ids[i] = author.ID
+		}
+		existing, err := existingValues(tx, &entities.Author{}, "id", ids)
+		if err != nil {
+			return err
+		}
+		created := []entities.Author{}
+		for _, author := range authors {
+			if _, ok := existing[author.ID]; !ok {
+				created = append(created, author)
+			}
+		}
+		if len(created) == 0 {
+			return nil
+		}
+		if err := createAll(tx, &created, len(created)); err != nil {
+			return err
+		}
+		entries := make([]entities.AuditEntry, len(created))
+		for i, author := range created {
+			entries[i] = auditEntry(tx, action, entities.AuditEntityAuthor, author.ID, nil, author.AuditFields())
+		}
+		return recordAudit(tx, entries...)
+	})
+}
+
+// insertBooks: writes the authors of the books and then the books in batches, rows that already exist are left as they are
+// a new book with the stock ID of another book fails the whole insert with ErrStockIDExists
+// an audit entry with the given action is recorded for every book and author that is created, the number of the created books is returned
+func insertBooks(db *gorm.DB, books []entities.Book, action entities.AuditAction) (int, error) {
+	books = uniqueBooks(books)
+	if len(books) == 0 {
+		return 0, nil
+	}
+	authors := make([]entities.Author, 0, len(books))
+	ids := make([]string, len(books))
+	for i, book := range books {
+		if book.Author != nil {
+			authors = append(authors, *book.Author)
+		}
+		ids[i] = book.ID
+	}
+	created := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := insertAuthors(tx, authors, action); err != nil {
+			return err
+		}
+		existing, err := existingValues(tx, &entities.Book{}, "id", ids)
+		if err != nil {
+			return err
+		}
+		newBooks := []entities.Book{}
+		for _, book := range books {
+			if _, ok := existing[book.ID]; !ok {
+				newBooks = append(newBooks, book)
+			}
+		}
+		if len(newBooks) == 0 {
+			return nil
+		}
+		if err := checkStockIDs(tx, newBooks); err != nil {
+			return err
+		}
+		if err := createAll(tx, &newBooks, len(newBooks)); err != nil {
+			return err
+		}
+		entries := make([]entities.AuditEntry, len(newBooks))
+		for i, book := range newBooks {
+			entries[i] = auditEntry(tx, action, entities.AuditEntityBook, book.ID, nil, book.AuditFields())
+		}
+		created = len(newBooks)
+		return recordAudit(tx, entries...)
+	})
+	return created, err
+}
+
+// errConcurrentInsert: returned when a row checked to be new is inserted by another transaction before the insert
+var errConcurrentInsert = errors.New("rows were inserted concurrently, retry the insert")
+
+// createAll: inserts the rows checked to be new, the audit entries are only recorded for the inserted rows
+// so the transaction fails if a row is skipped because another transaction inserted its ID in the meantime
+func createAll(tx *gorm.DB, rows interface{}, n int) error {
+	result := tx.Omit(clause.Associations).Clauses(onConflictID).CreateInBatches(rows, insertBatchSize)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(n) {
+		return fmt.Errorf("%w: %d of %d rows inserted", errConcurrentInsert, result.RowsAffected, n)
+	}
+	return nil
+}
+
+// checkStockIDs: returns ErrStockIDExists with the books whose stock ID is used by a stored book or by another of the books
+func checkStockIDs(tx *gorm.DB, books []entities.Book) error {
+	stockIDs := make([]string, len(books))
+	for i, book := range books {
+		stockIDs[i] = book.StockID
+	}
+	used, err := existingValues(tx, &entities.Book{}, "stock_id", stockIDs)
+	if err != nil {
+		return err
+	}
+	conflicts := []string{}
+	for _, book := range books {
+		if _, ok := used[book.StockID]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%q (book %s)", book.StockID, book.ID))
+		}
+		used[book.StockID] = struct{}{}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrStockIDExists, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// existingValues: returns which of the given values of the string column are already in the table of the model, including the soft deleted rows
+func existingValues(tx *gorm.DB, model interface{}, column string, values []string) (map[string]struct{}, error) {
+	existing := make(map[string]struct{}, len(values))
+	for start := 0; start < len(values); start += insertBatchSize {
+		end := start + insertBatchSize
+		if end > len(values) {
+			end = len(values)
+		}
+		found := []string{}
+		if err := tx.Unscoped().Model(model).Where(column+" IN ?", values[start:end]).Pluck(column, &found).Error; err != nil {
+			return nil, err
+		}
+		for _, value := range found {
+			existing[value] = struct{}{}
+		}
+	}
+	return existing, nil
+}
+
+// updateBooks: inserts the new books like insertBooks and overwrites the stored books with the fields of the given ones
+// including their deletion, a stored book that is deleted in the given ones is soft deleted and a deleted one that is not is restored
+// (the deletion time of a book deleted in both is kept)
+// every change is recorded in the audit trail (update, delete or restore), the numbers of the created and changed books are returned
+func updateBooks(db *gorm.DB, books []entities.Book, action entities.AuditAction) (int, int, error) {
+	books = uniqueBooks(books)
+	if len(books) == 0 {
+		return 0, 0, nil
+	}
+	ids := make([]string, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	created, changed := 0, 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		stored, err := storedBooks(tx, ids)
+		if err != nil {
+			return err
+		}
+		if created, err = insertBooks(tx, books, action); err != nil {
+			return err
+		}
+		entries := []entities.AuditEntry{}
+		for _, book := range books {
+			old, ok := stored[book.ID]
+			if !ok {
+				continue
+			}
+			recorded := len(entries)
+			before, after := old.AuditFields(), book.AuditFields()
+			if len(entities.Diff(before, after)) > 0 {
+				if err := tx.Unscoped().Model(&old).Select("name", "page_number", "stock_number", "stock_id", "price", "isbn", "author_id").
+					Updates(&entities.Book{Name: book.Name, PageNumber: book.PageNumber, StockNumber: book.StockNumber, StockID: book.StockID,
+						Price: book.Price, ISBN: book.ISBN, AuthorID: book.AuthorID}).Error; err != nil {
+					return err
+				}
+				entries = append(entries, auditEntry(tx, entities.AuditUpdate, entities.AuditEntityBook, book.ID, before, after))
+			}
+			switch {
+			case book.DeletedAt.Valid && !old.DeletedAt.Valid:
+				if err := tx.Unscoped().Model(&old).Update("deleted_at", book.DeletedAt).Error; err != nil {
+					return err
+				}
+				entries = append(entries, auditEntry(tx, entities.AuditDelete, entities.AuditEntityBook, book.ID, after, nil))
+			case !book.DeletedAt.Valid && old.DeletedAt.Valid:
+				if err := tx.Unscoped().Model(&old).Update("deleted_at", nil).Error; err != nil {
+					return err
+				}
+				entries = append(entries, auditEntry(tx, entities.AuditRestore, entities.AuditEntityBook, book.ID, nil, after))
+			}
+			if len(entries) > recorded {
+				changed++
+			}
+		}
+		return recordAudit(tx, entries...)
+	})
+	return created, changed, err
+}
+
+// storedBooks: returns the stored books with the given IDs by their IDs, including the soft deleted ones
+func storedBooks(tx *gorm.DB, ids []string) (map[string]entities.Book, error) {
+	stored := make(map[string]entities.Book, len(ids))
+	for start := 0; start < len(ids); start += insertBatchSize {
+		end := start + insertBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		books := []entities.Book{}
+		if err := tx.Unscoped().Where("id IN ?", ids[start:end]).Find(&books).Error; err != nil {
+			return nil, err
+		}
+		for _, book := range books {
+			stored[book.ID] = book
+		}
+	}
+	return stored, nil
+}
@@ -0,0 +1,231 @@
+package repos
+
+import (
+	"bookApp/internal/domain/entities"
+	"bookApp/internal/domain/migrations"
+	database "bookApp/pkg/db"
+	"bookApp/pkg/logger"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// benchmarkBooks: the number of rows of the file imported by the benchmarks, an author has ten books
+const benchmarkBooks = 2000
+
+// BenchmarkSetupDatabase: imports the same csv file into an empty in-memory database with the batched inserts
+// and with the row by row FirstOrCreate of the books the import used before, e.g.
+//
+//	go test ./internal/domain/repos -run - -bench SetupDatabase -benchmem
+//
+// the batched import records the audit entries as well, the row by row one does not
+func BenchmarkSetupDatabase(b *testing.B) {
+	silent, _ := logger.New(io.Discard, logger.LevelError, logger.FormatText)
+	logger.SetDefault(silent)
+	path := writeBenchmarkCSV(b, benchmarkBooks)
+	db := benchmarkDB(b)
+	ctx := context.Background()
+
+	b.Run("batched", func(b *testing.B) {
+		repo := NewBookRepository(db)
+		for i := 0; i < b.N; i++ {
+			emptyTables(b, db)
+			if err := repo.SetupDatabase(ctx, path, DefaultCSVProfile()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("row by row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			emptyTables(b, db)
+			books, _, _, err := readData(path, DefaultCSVProfile())
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, book := range books {
+				if err := firstOrCreateBook(db, book); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// firstOrCreateBook: the insert of a book and its author by the import before the batched inserts, a query and an insert per book
+func firstOrCreateBook(db *gorm.DB, book entities.Book) error {
+	return db.Unscoped().Where(entities.Book{ID: book.ID}).Attrs(entities.Book{ID: book.ID, Name: book.Name, PageNumber: book.PageNumber,
+		StockNumber: book.StockNumber, StockID: book.StockID, Price: book.Price, ISBN: book.ISBN,
+		Author: &entities.Author{ID: book.Author.ID, Name: book.Author.Name}}).FirstOrCreate(&book).Error
+}
+
+// benchmarkDB: the in-memory database with the schema migrated
+func benchmarkDB(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	db, err := database.NewMemoryDB()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+// emptyTables: removes the rows of the previous iteration, the time it takes is not measured
+func emptyTables(b *testing.B, db *gorm.DB) {
+	b.Helper()
+	b.StopTimer()
+	defer b.StartTimer()
+	deleteRows(b, db)
+}
+
+// deleteRows: removes the audit entries, books and authors including the soft deleted ones
+func deleteRows(tb testing.TB, db *gorm.DB) {
+	tb.Helper()
+	for _, model := range []interface{}{&entities.AuditEntry{}, &entities.Book{}, &entities.Author{}} {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(model).Error; err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// testDB: the empty in-memory database of a test, the database is shared by the tests of the package
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	silent, _ := logger.New(io.Discard, logger.LevelError, logger.FormatText)
+	logger.SetDefault(silent)
+	db := benchmarkDB(t)
+	deleteRows(t, db)
+	return db
+}
+
+// TestInsertBooksStockIDConflict: a new book with the stock ID of another book is an error, nothing of the insert is stored
+func TestInsertBooksStockIDConflict(t *testing.T) {
+	db := testDB(t)
+	repo := NewBookRepository(db)
+	ctx := context.Background()
+	author := &entities.Author{ID: "101", Name: "Charles Dickens"}
+	if err := repo.AddBook(ctx, entities.Book{ID: "1", Name: "A Tale of Two Cities", StockID: "21AC", AuthorID: "101", Author: author}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		books []entities.Book
+	}{
+		{"stock id of a stored book", []entities.Book{
+			{ID: "2", Name: "Bleak House", StockID: "21AC", AuthorID: "101", Author: author},
+		}},
+		{"stock id of another new book", []entities.Book{
+			{ID: "3", Name: "Hard Times", StockID: "33HT", AuthorID: "101", Author: author},
+			{ID: "4", Name: "Little Dorrit", StockID: "33HT", AuthorID: "101", Author: author},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := insertBooks(db.WithContext(ctx), tt.books, entities.AuditCreate)
+			if !errors.Is(err, ErrStockIDExists) {
+				t.Fatalf("error %v, want %v", err, ErrStockIDExists)
+			}
+			if created != 0 {
+				t.Errorf("%d books created", created)
+			}
+			var count int64
+			db.Unscoped().Model(&entities.Book{}).Count(&count)
+			if count != 1 {
+				t.Errorf("%d books stored, want the first book only", count)
+			}
+		})
+	}
+
+	// the existing book is skipped, its stock ID is not a conflict
+	created, err := insertBooks(db.WithContext(ctx), []entities.Book{{ID: "1", Name: "A Tale of Two Cities", StockID: "21AC", AuthorID: "101", Author: author}}, entities.AuditImport)
+	if err != nil || created != 0 {
+		t.Errorf("existing book: %d created, error %v", created, err)
+	}
+}
+
+// writeBenchmarkCSV: writes a file of the given number of books in the layout of data.csv
+func writeBenchmarkCSV(b *testing.B, n int) string {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), "books.csv")
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write(csvColumns)
+	for i := 1; i <= n; i++ {
+		author := strconv.Itoa(1000 + i/10)
+		w.Write([]string{strconv.Itoa(i), fmt.Sprintf("Book %d", i), "120", "10", fmt.Sprintf("SK%06d", i), "9.90",
+			fmt.Sprintf("978%010d", i), author, "Author " + author})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// TestInsertBooksAudit: an entry is recorded for every inserted book and author and for none of the skipped ones
+func TestInsertBooksAudit(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	audit := NewAuditRepository(db)
+	dickens := &entities.Author{ID: "101", Name: "Charles Dickens"}
+	tolkien := &entities.Author{ID: "202", Name: "J. R. R. Tolkien"}
+
+	steps := []struct {
+		name    string
+		books   []entities.Book
+		created int
+		err     error
+	}{
+		{"new books", []entities.Book{
+			{ID: "1", Name: "A Tale of Two Cities", StockID: "21AC", AuthorID: "101", Author: dickens},
+		}, 1, nil},
+		{"an existing and a new book", []entities.Book{
+			{ID: "1", Name: "A Tale of Two Cities", StockID: "21AC", AuthorID: "101", Author: dickens},
+			{ID: "2", Name: "The Hobbit", StockID: "44UY", AuthorID: "202", Author: tolkien},
+		}, 1, nil},
+		{"a reused stock id", []entities.Book{
+			{ID: "77", Name: "Bleak House", StockID: "21AC", AuthorID: "101", Author: dickens},
+			{ID: "78", Name: "Hard Times", StockID: "78HT", AuthorID: "303", Author: &entities.Author{ID: "303", Name: "Nobody"}},
+		}, 0, ErrStockIDExists},
+	}
+	for _, step := range steps {
+		created, err := insertBooks(db.WithContext(ctx), step.books, entities.AuditImport)
+		if !errors.Is(err, step.err) || created != step.created {
+			t.Fatalf("%s: %d created with error %v, want %d and %v", step.name, created, err, step.created, step.err)
+		}
+	}
+
+	want := map[string]int{"book 1": 1, "book 2": 1, "book 77": 0, "book 78": 0, "author 101": 1, "author 202": 1, "author 303": 0}
+	for key, n := range want {
+		var entity, id string
+		fmt.Sscan(key, &entity, &id)
+		entries, err := audit.FindByEntity(ctx, entity, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != n {
+			t.Errorf("%s has %d audit entries, want %d", key, len(entries), n)
+		}
+	}
+
+	// an empty id is not a condition that is left out
+	entries, err := audit.FindByEntity(ctx, entities.AuditEntityBook, "")
+	if err != nil || len(entries) != 0 {
+		t.Errorf("the entries of an empty id: %d, error %v", len(entries), err)
+	}
+}
@@ -0,0 +1,34 @@
+package repos
+
+import (
+	"bookApp/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrQueryTimeout: returned when a query is cancelled by the deadline of its context or by the statement timeout of the database
+var ErrQueryTimeout = fmt.Errorf("query timeout: %w", context.DeadlineExceeded)
+
+// ErrQueryCanceled: returned when a query is cancelled because the caller went away (e.g. the client disconnected)
+var ErrQueryCanceled = fmt.Errorf("query canceled: %w", context.Canceled)
+
+// queryError: replaces the errors caused by the context or the statement timeout with the respective query error
+// the error is recorded in the span of the repository call as well
+func queryError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("%w: %v", ErrQueryTimeout, err)
+	case strings.Contains(err.Error(), "SQLSTATE 57014"):
+		// query_canceled of postgres, raised by statement_timeout
+		err = fmt.Errorf("%w: %v", ErrQueryTimeout, err)
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		err = fmt.Errorf("%w: %v", ErrQueryCanceled, err)
+	}
+	tracing.RecordError(ctx, err)
+	return err
+}
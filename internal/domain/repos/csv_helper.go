@@ -2,42 +2,76 @@ package repos
 
 import (
 	"bookApp/internal/domain/entities"
+	"bufio"
 	"encoding/csv"
+	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Worker pool settings of the csv reader, they can be changed by the configuration before any import
+var (
+	CSVWorkers   = 3
+	CSVQueueSize = 5
 )
 
 // readDataWithWorkerPool: Reading a csv file concurrently and returns books and authors slices from the data in the file
-func readDataWithWorkerPool(path string) ([]entities.Book, []entities.Author, error) {
-	const numJobs = 5
+// the columns of the file are matched by its header with the given profile, the number of rows that cannot be parsed is returned as well
+func readDataWithWorkerPool(path string, profile CSVProfile) ([]entities.Book, []entities.Author, int, error) {
+	numJobs := CSVQueueSize
 	books := []entities.Book{}
 	authors := []entities.Author{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer f.Close()
+
+	r, err := newCSVReader(f, profile)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("cannot read csv header: %v", err)
+	}
+	mapping, err := profile.resolve(header)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
 	jobs := make(chan []string, numJobs)
 	resultsBooks := make(chan entities.Book, numJobs)
 	resultsAuthors := make(chan entities.Author, numJobs)
 	wg := sync.WaitGroup{}
 
-	for w := 1; w <= 3; w++ {
+	for w := 1; w <= CSVWorkers; w++ {
 		wg.Add(1)
-		go toStruct(jobs, resultsBooks, resultsAuthors, &wg)
+		go toStruct(jobs, mapping, resultsBooks, resultsAuthors, &wg)
 	}
-	go func() {
-		f, err := os.Open(path)
-		if err != nil {
-			return
-		}
-		defer f.Close()
 
-		lines, err := csv.NewReader(f).ReadAll()
-		if err != nil {
-			return
-		}
-		for _, line := range lines[1:] {
+	var readErr error
+	rows := 0
+	go func() {
+		defer close(jobs)
+		for {
+			line, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
+			rows++
 			jobs <- line
 		}
-
-		close(jobs)
 	}()
 
 	go func() {
@@ -46,47 +80,95 @@ func readDataWithWorkerPool(path string) ([]entities.Book, []entities.Author, er
 		close(resultsAuthors)
 	}()
 
+	// authors are drained concurrently so that workers never block on a full authors channel
+	authorsDone := make(chan struct{})
+	go func() {
+		for a := range resultsAuthors {
+			authors = append(authors, a)
+		}
+		close(authorsDone)
+	}()
 	for b := range resultsBooks {
 		books = append(books, b)
 	}
-	for a := range resultsAuthors {
-		authors = append(authors, a)
+	<-authorsDone
+
+	if readErr != nil {
+		return nil, nil, 0, fmt.Errorf("cannot read csv file: %v", readErr)
 	}
+	// the rows that could not be parsed are skipped by the workers
+	return books, authors, rows - len(books), nil
+}
 
-	return books, authors, nil
+// newCSVReader: creates a csv reader with the dialect of the profile, a leading UTF-8 BOM is skipped
+func newCSVReader(f io.Reader, profile CSVProfile) (*csv.Reader, error) {
+	comma, err := profile.comma()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+	r := csv.NewReader(br)
+	r.Comma = comma
+	r.LazyQuotes = profile.LazyQuotes
+	r.FieldsPerRecord = -1
+	return r, nil
 }
 
 // ToStruct: creates book and author structs as the data from the file is read and send the structs to respective results channels
-func toStruct(jobs <-chan []string, resultsBooks chan<- entities.Book, resultsAuthors chan<- entities.Author, wg *sync.WaitGroup) {
+// rows that cannot be parsed are skipped
+func toStruct(jobs <-chan []string, mapping csvMapping, resultsBooks chan<- entities.Book, resultsAuthors chan<- entities.Author, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for j := range jobs {
-		// parse string data read from csv file to the matching types with book struct
-		pageNumberParsed, err := strconv.Atoi(j[2])
-		if err != nil {
-			return
-		}
-		stockNumberParsed, err := strconv.Atoi(j[3])
-		if err != nil {
-			return
-		}
-		priceParsed, err := strconv.ParseFloat(j[5], 32)
+		book, err := mapping.toBook(j)
 		if err != nil {
-			return
+			continue
 		}
 
-		book := entities.Book{ID: j[0],
-			Name:        j[1],
-			PageNumber:  uint(pageNumberParsed),
-			StockNumber: stockNumberParsed,
-			StockID:     j[4],
-			Price:       float32(priceParsed),
-			ISBN:        j[6],
-			AuthorID:    j[7],
-			Author: &entities.Author{ID: j[7],
-				Name: j[8]}}
-
 		resultsAuthors <- *book.Author
 		resultsBooks <- book
 	}
 }
+
+// toBook: parse string data read from csv file to the matching types with book struct
+func (m csvMapping) toBook(record []string) (entities.Book, error) {
+	pageNumberParsed, err := strconv.Atoi(m.value(record, ColumnPageNumber))
+	if err != nil {
+		return entities.Book{}, err
+	}
+	stockNumberParsed, err := strconv.Atoi(m.value(record, ColumnStockNumber))
+	if err != nil {
+		return entities.Book{}, err
+	}
+	priceParsed, err := strconv.ParseFloat(m.value(record, ColumnPrice), 32)
+	if err != nil {
+		return entities.Book{}, err
+	}
+
+	book := entities.Book{ID: m.value(record, ColumnID),
+		Name:        m.value(record, ColumnName),
+		PageNumber:  uint(pageNumberParsed),
+		StockNumber: stockNumberParsed,
+		StockID:     m.value(record, ColumnStockID),
+		Price:       float32(priceParsed),
+		ISBN:        m.value(record, ColumnISBN),
+		AuthorID:    m.value(record, ColumnAuthorID),
+		Author: &entities.Author{ID: m.value(record, ColumnAuthorID),
+			Name: m.value(record, ColumnAuthorName)}}
+
+	if book.ID == "" || book.AuthorID == "" {
+		return entities.Book{}, fmt.Errorf("book id and author id are required")
+	}
+	// a book exported with the deleted ones is imported as deleted again
+	if deletedAt := m.value(record, ColumnDeletedAt); deletedAt != "" {
+		t, err := time.Parse(time.RFC3339Nano, deletedAt)
+		if err != nil {
+			return entities.Book{}, err
+		}
+		book.DeletedAt = gorm.DeletedAt{Time: t, Valid: true}
+	}
+	return book, nil
+}
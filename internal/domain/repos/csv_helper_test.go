@@ -0,0 +1,158 @@
+package repos
+
+import (
+	"bookApp/internal/domain/entities"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReadCSV: reads the sample files of testdata with the profile of the supplier layout
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		profile CSVProfile
+		want    []entities.Book
+		skipped int
+		err     string
+	}{
+		{
+			name:    "default layout",
+			file:    "default.csv",
+			profile: DefaultCSVProfile(),
+			want: []entities.Book{
+				{ID: "1", Name: "A Tale of Two Cities", PageNumber: 320, StockNumber: 10, StockID: "21AC", Price: 15.3, ISBN: "9780451530578", AuthorID: "101"},
+				{ID: "2", Name: "The Hobbit", PageNumber: 376, StockNumber: 10, StockID: "44UY", Price: 24, ISBN: "9780547928227", AuthorID: "202"},
+			},
+		},
+		{
+			name:    "utf-8 bom before the header",
+			file:    "bom.csv",
+			profile: DefaultCSVProfile(),
+			want: []entities.Book{
+				{ID: "1", Name: "A Tale of Two Cities", PageNumber: 320, StockNumber: 10, StockID: "21AC", Price: 15.3, ISBN: "9780451530578", AuthorID: "101"},
+			},
+		},
+		{
+			name:    "semicolon delimiter and header aliases in another order",
+			file:    "semicolon_aliases.csv",
+			profile: CSVProfile{Name: "supplier", Delimiter: "semicolon"},
+			want: []entities.Book{
+				{ID: "1", Name: "A Tale of Two Cities", PageNumber: 320, StockNumber: 10, StockID: "21AC", Price: 15.3, ISBN: "9780451530578", AuthorID: "101"},
+				{ID: "2", Name: "The Hobbit", PageNumber: 376, StockNumber: 10, StockID: "44UY", Price: 24, ISBN: "9780547928227", AuthorID: "202"},
+			},
+		},
+		{
+			name:    "tab delimiter with the defaults of the profile",
+			file:    "tab_defaults.csv",
+			profile: CSVProfile{Name: "supplier", Delimiter: "tab", Defaults: map[string]string{ColumnPrice: "9.5"}},
+			want: []entities.Book{
+				{ID: "7", Name: "Emma", StockID: "7JA", Price: 9.5, AuthorID: "303"},
+			},
+		},
+		{
+			name:    "profile aliases",
+			file:    "tab_defaults.csv",
+			profile: CSVProfile{Name: "supplier", Delimiter: "\t", Columns: map[string][]string{ColumnName: {"title"}}},
+			want: []entities.Book{
+				{ID: "7", Name: "Emma", StockID: "7JA", AuthorID: "303"},
+			},
+		},
+		{
+			name:    "quoted fields with delimiters, quotes and line breaks",
+			file:    "quoted.csv",
+			profile: DefaultCSVProfile(),
+			want: []entities.Book{
+				{ID: "1", Name: `The "Hobbit", Deluxe`, PageNumber: 376, StockNumber: 10, StockID: "44UY", Price: 24, ISBN: "9780547928227", AuthorID: "202"},
+				{ID: "2", Name: "Multi\nLine", PageNumber: 100, StockNumber: 1, StockID: "2ML", Price: 5, ISBN: "9780000000002", AuthorID: "303"},
+			},
+		},
+		{
+			name:    "a boolean deleted column is not a deletion time",
+			file:    "deleted_flag.csv",
+			profile: DefaultCSVProfile(),
+			want: []entities.Book{
+				{ID: "1", Name: "A Tale of Two Cities", PageNumber: 320, StockNumber: 10, StockID: "21AC", Price: 15.3, ISBN: "9780451530578", AuthorID: "101"},
+				{ID: "2", Name: "The Hobbit", PageNumber: 376, StockNumber: 10, StockID: "44UY", Price: 24, ISBN: "9780547928227", AuthorID: "202"},
+			},
+		},
+		{
+			name:    "rows that cannot be parsed are skipped",
+			file:    "invalid_rows.csv",
+			profile: DefaultCSVProfile(),
+			want: []entities.Book{
+				{ID: "2", Name: "The Hobbit", PageNumber: 376, StockNumber: 10, StockID: "44UY", Price: 24, ISBN: "9780547928227", AuthorID: "202"},
+			},
+			skipped: 2,
+		},
+		{
+			name:    "missing required columns",
+			file:    "missing_columns.csv",
+			profile: DefaultCSVProfile(),
+			err:     "csv header is missing required columns: stockId, authorId, authorName",
+		},
+		{
+			name:    "invalid delimiter",
+			file:    "default.csv",
+			profile: CSVProfile{Name: "supplier", Delimiter: "::"},
+			err:     `invalid csv delimiter "::" in profile supplier`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			books, authors, skipped, err := readData(filepath.Join("testdata", tt.file), tt.profile)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skipped != tt.skipped {
+				t.Errorf("skipped %d rows, want %d", skipped, tt.skipped)
+			}
+			if len(authors) != len(books) {
+				t.Errorf("%d authors of %d books", len(authors), len(books))
+			}
+			sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+			if len(books) != len(tt.want) {
+				t.Fatalf("read %d books, want %d", len(books), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := books[i]
+				if got.Author == nil || got.Author.ID != got.AuthorID {
+					t.Errorf("book %s: author %+v does not match the author id %s", got.ID, got.Author, got.AuthorID)
+				}
+				got.Author = nil
+				if got != want {
+					t.Errorf("book %d:\n got %+v\nwant %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestReadCSVDeletedAt: the deletion time exported with the deleted books is read back
+func TestReadCSVDeletedAt(t *testing.T) {
+	books, _, skipped, err := readData(filepath.Join("testdata", "deleted_at.csv"), DefaultCSVProfile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 0 || len(books) != 2 {
+		t.Fatalf("read %d books and skipped %d, want 2 and 0", len(books), skipped)
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+	want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	if !books[0].DeletedAt.Valid || !books[0].DeletedAt.Time.Equal(want) {
+		t.Errorf("book 1 deleted at %+v, want %s", books[0].DeletedAt, want)
+	}
+	if books[1].DeletedAt.Valid {
+		t.Errorf("book 2 deleted at %+v, want not deleted", books[1].DeletedAt)
+	}
+}
@@ -0,0 +1,201 @@
+package repos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Column keys of the book data that can be mapped from a csv header
+const (
+	ColumnID          = "id"
+	ColumnName        = "name"
+	ColumnPageNumber  = "pageNumber"
+	ColumnStockNumber = "stockNumber"
+	ColumnStockID     = "stockId"
+	ColumnPrice       = "price"
+	ColumnISBN        = "isbn"
+	ColumnAuthorID    = "authorId"
+	ColumnAuthorName  = "authorName"
+	ColumnDeletedAt   = "deletedAt"
+)
+
+// csvColumns: column keys in the order of the original nine column layout of data.csv
+var csvColumns = []string{
+	ColumnID,
+	ColumnName,
+	ColumnPageNumber,
+	ColumnStockNumber,
+	ColumnStockID,
+	ColumnPrice,
+	ColumnISBN,
+	ColumnAuthorID,
+	ColumnAuthorName,
+}
+
+// importColumns: the columns read by the import, the deletion time is optional and only exported with the deleted books
+var importColumns = append(append([]string{}, csvColumns...), ColumnDeletedAt)
+
+// defaultAliases: header names that are recognized for each column regardless of the profile
+var defaultAliases = map[string][]string{
+	ColumnID:          {"id", "book id"},
+	ColumnName:        {"name", "title", "book name"},
+	ColumnPageNumber:  {"page number", "pages", "page count", "extent"},
+	ColumnStockNumber: {"stock number", "stock", "quantity", "qty"},
+	ColumnStockID:     {"stock id", "sku"},
+	ColumnPrice:       {"price", "unit price"},
+	ColumnISBN:        {"isbn", "isbn13", "ean"},
+	ColumnAuthorID:    {"author id"},
+	ColumnAuthorName:  {"author name", "author", "contributor"},
+	ColumnDeletedAt:   {"deleted at"},
+}
+
+// defaultValues: values used for optional columns that are missing in a file
+var defaultValues = map[string]string{
+	ColumnPageNumber:  "0",
+	ColumnStockNumber: "0",
+	ColumnPrice:       "0",
+	ColumnISBN:        "",
+	ColumnDeletedAt:   "",
+}
+
+// CSVProfile: describes how the csv export of a supplier is mapped to book and author data
+type CSVProfile struct {
+	Name       string              `json:"name"`
+	Delimiter  string              `json:"delimiter,omitempty"`
+	LazyQuotes bool                `json:"lazyQuotes,omitempty"`
+	Columns    map[string][]string `json:"columns,omitempty"`
+	Defaults   map[string]string   `json:"defaults,omitempty"`
+}
+
+// DefaultCSVProfile: returns the profile matching the layout of data.csv
+func DefaultCSVProfile() CSVProfile {
+	return CSVProfile{Name: "default", Delimiter: ","}
+}
+
+// LoadCSVProfile: reads the profile saved for the given supplier from the profiles directory
+func LoadCSVProfile(dir, supplier string) (CSVProfile, error) {
+	profile := CSVProfile{}
+	data, err := os.ReadFile(profilePath(dir, supplier))
+	if err != nil {
+		return profile, fmt.Errorf("cannot read csv profile of %s: %v", supplier, err)
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return profile, fmt.Errorf("cannot parse csv profile of %s: %v", supplier, err)
+	}
+	if profile.Name == "" {
+		profile.Name = supplier
+	}
+	if _, err := profile.comma(); err != nil {
+		return profile, err
+	}
+	return profile, nil
+}
+
+// SaveCSVProfile: writes the profile to the profiles directory under the name of the profile
+func SaveCSVProfile(dir string, profile CSVProfile) error {
+	if profile.Name == "" {
+		return fmt.Errorf("csv profile name is required")
+	}
+	if _, err := profile.comma(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(profilePath(dir, profile.Name), data, 0o644)
+}
+
+func profilePath(dir, supplier string) string {
+	return filepath.Join(dir, filepath.Base(supplier)+".json")
+}
+
+// comma: returns the delimiter rune of the profile, comma is used if none is given
+func (p CSVProfile) comma() (rune, error) {
+	switch strings.ToLower(p.Delimiter) {
+	case "", ",", "comma":
+		return ',', nil
+	case ";", "semicolon":
+		return ';', nil
+	case "\t", "\\t", "tab":
+		return '\t', nil
+	case "|", "pipe":
+		return '|', nil
+	}
+	r, size := utf8.DecodeRuneInString(p.Delimiter)
+	if size != len(p.Delimiter) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid csv delimiter %q in profile %s", p.Delimiter, p.Name)
+	}
+	return r, nil
+}
+
+// csvMapping: column positions resolved from the header of a csv file with a profile
+type csvMapping struct {
+	index    map[string]int
+	defaults map[string]string
+}
+
+// resolve: matches the header of a file to the columns of the profile
+// every column that is neither found in the header nor has a default value is reported as missing
+func (p CSVProfile) resolve(header []string) (csvMapping, error) {
+	m := csvMapping{index: map[string]int{}, defaults: map[string]string{}}
+
+	positions := map[string]int{}
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		key := normalizeHeader(h)
+		if _, ok := positions[key]; !ok {
+			positions[key] = i
+		}
+	}
+
+	missing := []string{}
+	for _, column := range importColumns {
+		aliases := append(append([]string{column}, p.Columns[column]...), defaultAliases[column]...)
+		for _, alias := range aliases {
+			if i, ok := positions[normalizeHeader(alias)]; ok {
+				m.index[column] = i
+				break
+			}
+		}
+		if value, ok := p.Defaults[column]; ok {
+			m.defaults[column] = value
+		} else if value, ok := defaultValues[column]; ok {
+			m.defaults[column] = value
+		}
+		_, found := m.index[column]
+		_, optional := m.defaults[column]
+		if !found && !optional {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return m, fmt.Errorf("csv header is missing required columns: %s", strings.Join(missing, ", "))
+	}
+	return m, nil
+}
+
+// value: returns the value of the column in the given record or the default of the column if the field is empty
+func (m csvMapping) value(record []string, column string) string {
+	if i, ok := m.index[column]; ok && i < len(record) {
+		if v := strings.TrimSpace(record[i]); v != "" {
+			return v
+		}
+	}
+	return m.defaults[column]
+}
+
+// normalizeHeader: lower cases the header and strips separators so that "Author Name", "author_name" and "authorName" match
+func normalizeHeader(h string) string {
+	replacer := strings.NewReplacer(" ", "", "_", "", "-", "", ".", "")
+	return replacer.Replace(strings.ToLower(strings.TrimSpace(h)))
+}
@@ -0,0 +1,17 @@
+package repos
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// whereContains: filters the column to contain the given value case insensitively
+// ILIKE is only supported by postgres, the other databases compare the lower cased values
+func whereContains(db *gorm.DB, column, value string) *gorm.DB {
+	pattern := fmt.Sprintf("%%%s%%", value)
+	if db.Dialector.Name() == "postgres" {
+		return db.Where(fmt.Sprintf("%s ILIKE ?", column), pattern)
+	}
+	return db.Where(fmt.Sprintf("LOWER(%s) LIKE LOWER(?)", column), pattern)
+}
@@ -0,0 +1,149 @@
+package repos
+
+import (
+	"bookApp/internal/domain/entities"
+	"bookApp/internal/domain/onix"
+	"bookApp/internal/tracing"
+	"bookApp/pkg/export"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const exportBatchSize = 500
+
+// BookExportColumns: columns of the book export, in the same layout as data.csv so that the export can be imported again
+// the deletion time is added when the deleted books are exported, so that they are imported as deleted
+func BookExportColumns(includeDeleted bool) []string {
+	if includeDeleted {
+		return importColumns
+	}
+	return csvColumns
+}
+
+// AuthorExportColumns: columns of the author export
+var AuthorExportColumns = []string{ColumnAuthorID, ColumnAuthorName}
+
+// BookRow: returns the values of the book in the order of BookExportColumns, the deletion time is empty for the books that are not deleted
+func BookRow(book entities.Book, includeDeleted bool) []interface{} {
+	authorName := ""
+	if book.Author != nil {
+		authorName = book.Author.Name
+	}
+	row := []interface{}{book.ID, book.Name, book.PageNumber, book.StockNumber, book.StockID, book.Price, book.ISBN, book.AuthorID, authorName}
+	if includeDeleted {
+		var deletedAt interface{}
+		if book.DeletedAt.Valid {
+			deletedAt = book.DeletedAt.Time.UTC().Format(time.RFC3339Nano)
+		}
+		row = append(row, deletedAt)
+	}
+	return row
+}
+
+// AuthorRow: returns the values of the author in the order of AuthorExportColumns
+func AuthorRow(author entities.Author) []interface{} {
+	return []interface{}{author.ID, author.Name}
+}
+
+// ExportBooks: reads all the books with their authors in batches and passes every batch to the given function
+// so that the whole catalogue is never loaded into memory at once
+func (b *BookRepository) ExportBooks(ctx context.Context, includeDeleted bool, fn func([]entities.Book) error) error {
+	ctx, span := tracing.Start(ctx, "BookRepository.ExportBooks")
+	defer span.End()
+	db := b.db.WithContext(ctx)
+	if includeDeleted {
+		db = db.Unscoped()
+	}
+	books := []entities.Book{}
+	result := db.Preload("Author", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}).FindInBatches(&books, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		return fn(books)
+	})
+	return queryError(ctx, result.Error)
+}
+
+// ExportAuthors: reads all the authors in batches and passes every batch to the given function
+func (a *AuthorRepository) ExportAuthors(ctx context.Context, includeDeleted bool, fn func([]entities.Author) error) error {
+	ctx, span := tracing.Start(ctx, "AuthorRepository.ExportAuthors")
+	defer span.End()
+	db := a.db.WithContext(ctx)
+	if includeDeleted {
+		db = db.Unscoped()
+	}
+	authors := []entities.Author{}
+	result := db.FindInBatches(&authors, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		return fn(authors)
+	})
+	return queryError(ctx, result.Error)
+}
+
+// WriteBooks: writes all the books to w in the given format
+func (b *BookRepository) WriteBooks(ctx context.Context, w io.Writer, format export.Format, includeDeleted bool) error {
+	ctx, span := tracing.Start(ctx, "BookRepository.WriteBooks")
+	defer span.End()
+	if format == export.ONIX {
+		encoder, err := onix.NewEncoder(w, "bookApp")
+		if err != nil {
+			return err
+		}
+		err = b.ExportBooks(ctx, includeDeleted, func(books []entities.Book) error {
+			for _, book := range books {
+				if err := encoder.Encode(book); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	writer, err := export.NewWriter(format, w, BookExportColumns(includeDeleted))
+	if err != nil {
+		return err
+	}
+	err = b.ExportBooks(ctx, includeDeleted, func(books []entities.Book) error {
+		for _, book := range books {
+			if err := writer.Write(BookRow(book, includeDeleted)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// WriteAuthors: writes all the authors to w in the given format
+func (a *AuthorRepository) WriteAuthors(ctx context.Context, w io.Writer, format export.Format, includeDeleted bool) error {
+	ctx, span := tracing.Start(ctx, "AuthorRepository.WriteAuthors")
+	defer span.End()
+	if format == export.ONIX {
+		return fmt.Errorf("authors cannot be exported as onix, export the books instead")
+	}
+	writer, err := export.NewWriter(format, w, AuthorExportColumns)
+	if err != nil {
+		return err
+	}
+	err = a.ExportAuthors(ctx, includeDeleted, func(authors []entities.Author) error {
+		for _, author := range authors {
+			if err := writer.Write(AuthorRow(author)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,169 @@
+package repos
+
+import (
+	"bookApp/internal/domain/entities"
+	"bookApp/pkg/export"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// exportedState: seeds the books of testdata/default.csv, adds a third one, orders a copy of the second and deletes the first
+// the stored books are returned by their IDs including the deleted one
+func exportedState(t *testing.T, db *gorm.DB) map[string]entities.Book {
+	t.Helper()
+	repo := NewBookRepository(db)
+	ctx := context.Background()
+	if err := repo.SetupDatabase(ctx, filepath.Join("testdata", "default.csv"), DefaultCSVProfile()); err != nil {
+		t.Fatal(err)
+	}
+	emma := entities.Book{ID: "3", Name: "Emma", PageNumber: 474, StockNumber: 2, StockID: "7JA", Price: 9.5, ISBN: "9780141439587",
+		AuthorID: "303", Author: &entities.Author{ID: "303", Name: "Jane Austen"}}
+	if err := repo.AddBook(ctx, emma); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.BuyByBookID(ctx, "2", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteByBookID(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	return booksByID(t, repo)
+}
+
+// exportFile: writes the books including the deleted ones to a file of the format
+func exportFile(t *testing.T, db *gorm.DB, format export.Format) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "books."+format.Extension())
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewBookRepository(db).WriteBooks(context.Background(), f, format, true); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func booksByID(t *testing.T, repo *BookRepository) map[string]entities.Book {
+	t.Helper()
+	books, err := repo.FindAllIncludingDeleted(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]entities.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+	return byID
+}
+
+// compareBooks: the fields of the books must match, the deletion times as well if exactTime is set
+// (an ONIX record carries no deletion time, the book is deleted as of the import)
+func compareBooks(t *testing.T, got, want map[string]entities.Book, exactTime bool) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%d books, want %d", len(got), len(want))
+	}
+	for id, w := range want {
+		g, ok := got[id]
+		if !ok {
+			t.Errorf("book %s is missing", id)
+			continue
+		}
+		if changes := entities.Diff(w.AuditFields(), g.AuditFields()); len(changes) > 0 {
+			t.Errorf("book %s differs: %+v", id, changes)
+		}
+		if g.DeletedAt.Valid != w.DeletedAt.Valid || (exactTime && !g.DeletedAt.Time.Equal(w.DeletedAt.Time)) {
+			t.Errorf("book %s deleted at %+v, want %+v", id, g.DeletedAt, w.DeletedAt)
+		}
+	}
+}
+
+// TestExportImportRoundTrip: an export with the deleted books imported into an empty database restores the books it was taken of
+func TestExportImportRoundTrip(t *testing.T) {
+	tests := []struct {
+		format    export.Format
+		exactTime bool
+	}{
+		{export.CSV, true},
+		{export.ONIX, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			db := testDB(t)
+			want := exportedState(t, db)
+			path := exportFile(t, db, tt.format)
+
+			deleteRows(t, db)
+			repo := NewBookRepository(db)
+			if err := repo.InsertBookData(context.Background(), path, DefaultCSVProfile()); err != nil {
+				t.Fatal(err)
+			}
+			compareBooks(t, booksByID(t, repo), want, tt.exactTime)
+		})
+	}
+}
+
+// TestUpdateBookData: a plain import leaves the stored books as they are, an import with update overwrites them
+// with the export including their deletion and records every change in the audit trail
+func TestUpdateBookData(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewBookRepository(db)
+	want := exportedState(t, db)
+	path := exportFile(t, db, export.CSV)
+
+	if _, err := repo.RestoreByBookID(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.SetStock(ctx, "2", 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteByBookID(ctx, "3"); err != nil {
+		t.Fatal(err)
+	}
+	changed := booksByID(t, repo)
+
+	if err := repo.InsertBookData(ctx, path, DefaultCSVProfile()); err != nil {
+		t.Fatal(err)
+	}
+	compareBooks(t, booksByID(t, repo), changed, true)
+
+	if err := repo.UpdateBookData(ctx, path, DefaultCSVProfile()); err != nil {
+		t.Fatal(err)
+	}
+	compareBooks(t, booksByID(t, repo), want, true)
+
+	audit := NewAuditRepository(db)
+	for id, action := range map[string]entities.AuditAction{"1": entities.AuditDelete, "2": entities.AuditUpdate, "3": entities.AuditRestore} {
+		entries, err := audit.FindByEntity(ctx, entities.AuditEntityBook, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) == 0 || entries[len(entries)-1].Action != action {
+			t.Errorf("book %s: last audit entry of %d is not %s", id, len(entries), action)
+		}
+	}
+
+	// the books are in the state of the export already, nothing is changed again
+	created, updated, err := updateBooks(db.WithContext(ctx), mapBooks(want), entities.AuditImport)
+	if err != nil || created != 0 || updated != 0 {
+		t.Errorf("second update: %d created, %d changed, error %v", created, updated, err)
+	}
+}
+
+func mapBooks(byID map[string]entities.Book) []entities.Book {
+	books := make([]entities.Book, 0, len(byID))
+	for _, book := range byID {
+		books = append(books, book)
+	}
+	return books
+}
@@ -0,0 +1,49 @@
+package repos
+
+import (
+	"bookApp/internal/domain/entities"
+	"bookApp/internal/domain/onix"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importFormat: returns the format a file is read in by its extension, onix or csv
+func importFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml", ".onix":
+		return "onix"
+	}
+	return "csv"
+}
+
+// readData: returns books and authors from the file in the given path and the number of skipped rows that cannot be parsed
+// ONIX messages (.xml, .onix) are read as ONIX 3.0, any other file is read as csv with the given profile
+func readData(path string, profile CSVProfile) ([]entities.Book, []entities.Author, int, error) {
+	if importFormat(path) == "onix" {
+		return readONIX(path)
+	}
+	return readDataWithWorkerPool(path, profile)
+}
+
+// readONIX: returns books and authors from the products of the ONIX message in the given path
+func readONIX(path string) ([]entities.Book, []entities.Author, int, error) {
+	books := []entities.Book{}
+	authors := []entities.Author{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer f.Close()
+
+	skipped, err := onix.Decode(f, func(book entities.Book) error {
+		books = append(books, book)
+		authors = append(authors, *book.Author)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return books, authors, skipped, nil
+}
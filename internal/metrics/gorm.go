@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// GormPlugin: observes the duration of every statement executed by gorm in DBQueryDuration
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize: registers a callback before and after each gorm operation
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return firstError(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", start),
+		cb.Create().After("gorm:create").Register("metrics:after_create", observe("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", start),
+		cb.Query().After("gorm:query").Register("metrics:after_query", observe("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", start),
+		cb.Update().After("gorm:update").Register("metrics:after_update", observe("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", start),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", observe("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", start),
+		cb.Row().After("gorm:row").Register("metrics:after_row", observe("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", start),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", observe("raw")),
+	)
+}
+
+// start: keeps the start time of the statement in the instance of the operation
+func start(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+// observe: records the duration since start with the table and the result of the statement
+func observe(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		begin, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		result := "ok"
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			result = "error"
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		DBQueryDuration.WithLabelValues(operation, table, result).Observe(time.Since(begin).Seconds())
+	}
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
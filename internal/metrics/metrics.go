@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bookapp"
+
+// Registry: the collectors served at /metrics, the go runtime and process collectors are included
+var Registry = prometheus.NewRegistry()
+
+// HTTP metrics, labeled by the path template of the mux route so that ids in the path do not create new series
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of http requests by route template, method (OTHER for the non standard ones) and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the http requests by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+)
+
+// RateLimitRejections: requests rejected with 429 by route group
+var RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "http",
+	Name:      "rate_limited_total",
+	Help:      "Number of requests rejected by the rate limit by route group.",
+}, []string{"group"})
+
+// DeprecatedRequests: requests of the deprecated routes by route template, they can be removed once no client calls them
+var DeprecatedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "http",
+	Name:      "deprecated_requests_total",
+	Help:      "Number of requests of the deprecated routes by route template.",
+}, []string{"route"})
+
+// DBQueryDuration: duration of the sql statements by gorm operation (create, query, update, delete, row, raw) and table
+var DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "db",
+	Name:      "query_duration_seconds",
+	Help:      "Duration of the sql statements by operation, table and result.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+}, []string{"operation", "table", "result"})
+
+// Business metrics of the catalogue
+var (
+	BooksOrdered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "books_ordered_total",
+		Help:      "Number of books sold by the successful orders.",
+	})
+
+	AuthorUnitsSold = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "author_units_sold_total",
+		Help:      "Number of books sold by author id.",
+	}, []string{"author_id"})
+
+	OutOfStockRejections = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "out_of_stock_rejections_total",
+		Help:      "Number of orders rejected because there is not enough stock.",
+	})
+
+	ImportRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "import_rows_total",
+		Help:      "Number of rows (csv) or products (onix) read by the imports, skipped rows could not be parsed.",
+	}, []string{"format", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		RateLimitRejections,
+		DeprecatedRequests,
+		DBQueryDuration,
+		BooksOrdered,
+		AuthorUnitsSold,
+		OutOfStockRejections,
+		ImportRows,
+	)
+}
+
+// Handler: serves the collectors of the registry in the prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// methodOther: the method label of the requests with a non standard method
+const methodOther = "OTHER"
+
+// methods: the methods labeled by their name, any method is accepted by net/http so the others share a label
+var methods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// methodLabel: the method label of a request, the methods not in methods are labeled OTHER
+func methodLabel(method string) string {
+	if methods[method] {
+		return method
+	}
+	return methodOther
+}
+
+// ObserveHTTP: counts a finished request and observes its latency, route is the path template of the matched route
+func ObserveHTTP(route, method string, status int, d time.Duration) {
+	method = methodLabel(method)
+	HTTPRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	HTTPDuration.WithLabelValues(route, method).Observe(d.Seconds())
+}
@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveHTTPMethodLabel(t *testing.T) {
+	for _, method := range []string{"GET", "PATCH", "PURGE", "get", "X-RANDOM-1"} {
+		ObserveHTTP("/test", method, 200, time.Millisecond)
+	}
+	for method, want := range map[string]float64{"GET": 1, "PATCH": 1, methodOther: 3, "PURGE": 0, "get": 0} {
+		if got := testutil.ToFloat64(HTTPRequests.WithLabelValues("/test", method, "200")); got != want {
+			t.Errorf("requests with the method label %q = %v, want %v", method, got, want)
+		}
+	}
+}
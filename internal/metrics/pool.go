@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	database "bookApp/pkg/db"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector: reports the connection pool stats and the health of the database monitor when it is scraped
+type poolCollector struct {
+	monitor *database.Monitor
+
+	maxOpen      *prometheus.Desc
+	open         *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+	closed       *prometheus.Desc
+	up           *prometheus.Desc
+	failures     *prometheus.Desc
+}
+
+// RegisterPool: adds the pool gauges of the database observed by the monitor to the registry
+func RegisterPool(monitor *database.Monitor) error {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", name), help, labels, nil)
+	}
+	return Registry.Register(&poolCollector{
+		monitor:      monitor,
+		maxOpen:      desc("max_open_connections", "Maximum number of open connections of the pool."),
+		open:         desc("open_connections", "Number of established connections, in use or idle."),
+		inUse:        desc("in_use_connections", "Number of connections in use."),
+		idle:         desc("idle_connections", "Number of idle connections."),
+		waitCount:    desc("wait_count_total", "Number of connections waited for."),
+		waitDuration: desc("wait_duration_seconds_total", "Time blocked waiting for a new connection."),
+		closed:       desc("closed_connections_total", "Number of connections closed by the pool by reason.", "reason"),
+		up:           desc("up", "Whether the last health probe of the database succeeded."),
+		failures:     desc("consecutive_probe_failures", "Number of health probes failed in a row."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.maxOpen, c.open, c.inUse, c.idle, c.waitCount, c.waitDuration, c.closed, c.up, c.failures} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.monitor.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.closed, prometheus.CounterValue, float64(s.MaxIdleClosed), "max_idle")
+	ch <- prometheus.MustNewConstMetric(c.closed, prometheus.CounterValue, float64(s.MaxIdleTimeClosed), "max_idle_time")
+	ch <- prometheus.MustNewConstMetric(c.closed, prometheus.CounterValue, float64(s.MaxLifetimeClosed), "max_lifetime")
+
+	h := c.monitor.Health()
+	up := 0.0
+	if h.Healthy {
+		up = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up)
+	ch <- prometheus.MustNewConstMetric(c.failures, prometheus.GaugeValue, float64(h.ConsecutiveFailures))
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval: how often the buckets that are full again are removed from the memory store
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// MemoryStore: keeps the buckets in the memory of the process, the budgets are per instance
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore: creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, lastSweep: time.Now()}
+}
+
+// Take: refills the bucket of the key for the time passed since the last request and takes a token if there is one
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Burst), last: now, limit: limit}
+		s.buckets[key] = b
+	}
+	b.refill(now)
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = tokenTime(1-b.tokens, limit)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = tokenTime(float64(limit.Burst)-b.tokens, limit)
+	return result, nil
+}
+
+// refill: adds the tokens of the time passed since the last refill
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.last = now
+	}
+}
+
+// sweep: removes the buckets that are full again, they are created again on the next request of their caller
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// tokenTime: the time needed to refill the given number of tokens
+func tokenTime(tokens float64, limit Limit) time.Duration {
+	return time.Duration(tokens / limit.Rate * float64(time.Second))
+}
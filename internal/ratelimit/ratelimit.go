@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Group: the routes sharing a budget, a caller has a separate bucket in every group
+type Group string
+
+const (
+	GroupRead   Group = "read"
+	GroupSearch Group = "search"
+	GroupWrite  Group = "write"
+	// GroupNone: routes that are never limited, e.g. the probes
+	GroupNone Group = "none"
+)
+
+// Limit: a token bucket refilled with Rate tokens per second holding at most Burst tokens, a rate of 0 disables the limit
+type Limit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// Unlimited: reports whether the limit is disabled
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// Window: the time an empty bucket needs to be full again
+func (l Limit) Window() time.Duration {
+	if l.Unlimited() {
+		return 0
+	}
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// Config: the budgets of the route groups and the proxies whose X-Forwarded-For header is trusted
+type Config struct {
+	Read           Limit  `yaml:"read"`
+	Search         Limit  `yaml:"search"`
+	Write          Limit  `yaml:"write"`
+	TrustedProxies string `yaml:"trustedProxies"`
+}
+
+// Result: the state of the bucket after a request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter: the time until the next request is allowed, 0 if it is allowed
+	RetryAfter time.Duration
+	// Reset: the time until the bucket is full again
+	Reset time.Duration
+}
+
+// Store: keeps the buckets of the callers, the memory store is used by a single instance
+// a shared backend (e.g. redis) lets several instances enforce a common budget
+type Store interface {
+	// Take: takes a token from the bucket of the key if there is one
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter: applies the limits of the route groups with the buckets of the store
+type Limiter struct {
+	store  Store
+	limits map[Group]Limit
+}
+
+// New: creates a limiter of the group budgets of the config
+func New(store Store, c Config) *Limiter {
+	return &Limiter{store: store, limits: map[Group]Limit{
+		GroupRead:   c.Read,
+		GroupSearch: c.Search,
+		GroupWrite:  c.Write,
+	}}
+}
+
+// Limit: returns the limit of the group, the groups without a budget are unlimited
+func (l *Limiter) Limit(group Group) Limit {
+	return l.limits[group]
+}
+
+// Take: takes a token from the bucket of the caller in the group, the group must be limited
+func (l *Limiter) Take(ctx context.Context, group Group, caller string) (Result, error) {
+	return l.store.Take(ctx, string(group)+":"+caller, l.Limit(group))
+}
+
+// Headers: sets the RateLimit-* headers of the result and the Retry-After header of a rejected request
+func (r Result) Headers(h http.Header, limit Limit) {
+	h.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(r.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, seconds(limit.Window())))
+	if !r.Allowed {
+		h.Set("Retry-After", strconv.Itoa(seconds(r.RetryAfter)))
+	}
+}
+
+// seconds: the duration in whole seconds rounded up, so clients never retry too early
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ParseProxies: parses a comma separated list of ip addresses and cidr ranges
+func ParseProxies(s string) ([]*net.IPNet, error) {
+	proxies := []*net.IPNet{}
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		cidr := p
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address %q", p)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// ClientIP: returns the address of the client of the request
+// the X-Forwarded-For header is only followed while the request comes through the trusted proxies
+func ClientIP(r *http.Request, trusted []*net.IPNet) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if !isTrusted(ip, trusted) {
+		return ip
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	// the last addresses were appended by the closest proxies, the first untrusted one is the client
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !isTrusted(hop, trusted) {
+			break
+		}
+	}
+	return ip
+}
+
+func isTrusted(ip string, trusted []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	spanKey   = "tracing:span"
+	parentKey = "tracing:parent"
+)
+
+// GormPlugin: creates a span for every statement executed by gorm as a child of the span in the statement context
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize: registers a callback before and after each gorm operation
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return firstError(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", start("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", end),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", start("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", end),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", start("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", end),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", start("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", end),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", start("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", end),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", start("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", end),
+	)
+}
+
+// start: starts the span of the statement, its name is the operation and the table like "gorm.query books"
+func start(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		name := "gorm." + operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		ctx, span := Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBOperationKey.String(operation),
+				semconv.DBSQLTableKey.String(db.Statement.Table),
+			))
+		// the statement runs with the span in its context, the parent is restored at the end
+		// so that the next statements of the session are not nested in this one
+		db.InstanceSet(parentKey, db.Statement.Context)
+		db.InstanceSet(spanKey, span)
+		db.Statement.Context = ctx
+	}
+}
+
+// end: ends the span of the statement with the executed sql, a record that is not found is not a failure
+func end(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+	if parent, ok := db.InstanceGet(parentKey); ok {
+		defer func() { db.Statement.Context = parent.(context.Context) }()
+	}
+
+	span.SetAttributes(
+		semconv.DBStatementKey.String(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		RecordError(db.Statement.Context, db.Error)
+	}
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
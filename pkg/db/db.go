@@ -0,0 +1,27 @@
+package db
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Supported storage backends
+const (
+	DriverPostgres = "postgres"
+	DriverSqlite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Open: opens the database of the configured backend
+func Open(c Config) (*gorm.DB, error) {
+	switch c.Driver {
+	case DriverPostgres, "":
+		return NewPsqlDB(c)
+	case DriverSqlite:
+		return NewSqliteDB(c.Path)
+	case DriverMemory:
+		return NewMemoryDB()
+	}
+	return nil, fmt.Errorf("database driver %s is not supported", c.Driver)
+}
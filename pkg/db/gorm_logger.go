@@ -0,0 +1,73 @@
+package db
+
+import (
+	"bookApp/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// SlowQueryThreshold: statements taking longer than this are logged as warnings
+var SlowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger: writes the logs of gorm through the logger of the query context,
+// so that the statements of a request are logged with its request id
+type gormLogger struct {
+	level gormlogger.LogLevel
+}
+
+// newGormConfig: the gorm settings shared by every backend
+func newGormConfig() *gorm.Config {
+	return &gorm.Config{Logger: gormLogger{level: gormlogger.Warn}}
+}
+
+func (g gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return gormLogger{level: level}
+}
+
+func (g gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Info {
+		logger.FromContext(ctx).Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (g gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Warn {
+		logger.FromContext(ctx).Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (g gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Error {
+		logger.FromContext(ctx).Error(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace: logs failed and slow statements as warnings, every other statement at debug level
+// a record that is not found is not a failure of the database, it is reported to the client
+func (g gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.level <= gormlogger.Silent {
+		return
+	}
+	l := logger.FromContext(ctx)
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.level >= gormlogger.Error:
+		sql, rows := fc()
+		l.Warn("query failed", "sql", sql, "rows", rows, "duration_ms", milliseconds(elapsed), "error", err)
+	case elapsed > SlowQueryThreshold && SlowQueryThreshold > 0 && g.level >= gormlogger.Warn:
+		sql, rows := fc()
+		l.Warn("slow query", "sql", sql, "rows", rows, "duration_ms", milliseconds(elapsed))
+	case l.Enabled(logger.LevelDebug):
+		sql, rows := fc()
+		l.Debug("query", "sql", sql, "rows", rows, "duration_ms", milliseconds(elapsed))
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"bookApp/pkg/logger"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HealthConfig: how often the database is probed, failed probes are retried with an exponential backoff up to MaxBackoff
+type HealthConfig struct {
+	Interval   time.Duration `yaml:"interval"`
+	Timeout    time.Duration `yaml:"timeout"`
+	MaxBackoff time.Duration `yaml:"maxBackoff"`
+}
+
+// Health: result of the last probe of the database
+type Health struct {
+	Healthy             bool      `json:"healthy"`
+	Error               string    `json:"error,omitempty"`
+	LastCheck           time.Time `json:"lastCheck"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+}
+
+// PoolStats: usage of the connection pool
+type PoolStats struct {
+	MaxOpenConnections int           `json:"maxOpenConnections"`
+	OpenConnections    int           `json:"openConnections"`
+	InUse              int           `json:"inUse"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"waitCount"`
+	WaitDuration       time.Duration `json:"waitDuration"`
+	MaxIdleClosed      int64         `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64         `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64         `json:"maxLifetimeClosed"`
+}
+
+// Monitor: probes the database in the background and keeps its health and pool stats
+type Monitor struct {
+	sqlDB        *sql.DB
+	config       HealthConfig
+	maxIdleConns int
+	// flushIdle: the idle connections are dropped when the database becomes unreachable, not with sqlite
+	// whose connections cannot break and whose in-memory database is destroyed with its last connection
+	flushIdle bool
+
+	mu     sync.RWMutex
+	health Health
+}
+
+// defaultMaxIdleConns: the number of idle connections database/sql keeps if it is not configured
+const defaultMaxIdleConns = 2
+
+// NewMonitor: creates a monitor of the database, the database is assumed healthy until the first probe
+func NewMonitor(db *gorm.DB, config HealthConfig, maxIdleConns int) (*Monitor, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if maxIdleConns <= 0 {
+		maxIdleConns = defaultMaxIdleConns
+	}
+	return &Monitor{
+		sqlDB:        sqlDB,
+		config:       config,
+		maxIdleConns: maxIdleConns,
+		flushIdle:    db.Dialector.Name() != "sqlite",
+		health:       Health{Healthy: true, LastCheck: time.Now()},
+	}, nil
+}
+
+// Start: probes the database until the context is cancelled
+func (m *Monitor) Start(ctx context.Context) {
+	wait := m.config.Interval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if m.Probe(ctx) {
+			wait = m.config.Interval
+			continue
+		}
+		// back off while the database is unreachable
+		wait *= 2
+		if wait > m.config.MaxBackoff {
+			wait = m.config.MaxBackoff
+		}
+	}
+}
+
+// Probe: pings the database once and records the result
+func (m *Monitor) Probe(ctx context.Context) bool {
+	pingCtx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	defer cancel()
+	err := m.sqlDB.PingContext(pingCtx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wasHealthy := m.health.Healthy
+	m.health.LastCheck = time.Now()
+	if err != nil {
+		m.health.Healthy = false
+		m.health.Error = err.Error()
+		m.health.ConsecutiveFailures++
+		if wasHealthy {
+			logger.FromContext(ctx).Error("database is unreachable", "error", err)
+		}
+		if wasHealthy && m.flushIdle {
+			// idle connections are probably broken, drop them so that the next ones are dialed again
+			m.sqlDB.SetMaxIdleConns(-1)
+			m.sqlDB.SetMaxIdleConns(m.maxIdleConns)
+		}
+		return false
+	}
+	if !wasHealthy {
+		logger.FromContext(ctx).Info("database is reachable again", "failed_probes", m.health.ConsecutiveFailures)
+	}
+	m.health = Health{Healthy: true, LastCheck: m.health.LastCheck}
+	return true
+}
+
+// Health: returns the result of the last probe
+func (m *Monitor) Health() Health {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.health
+}
+
+// Stats: returns the current usage of the connection pool
+func (m *Monitor) Stats() PoolStats {
+	s := m.sqlDB.Stats()
+	return PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
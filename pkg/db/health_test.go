@@ -0,0 +1,50 @@
+package db
+
+import (
+	"bookApp/pkg/logger"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestProbeKeepsMemoryDB: a failed probe does not drop the only connection of the in-memory database and its data with it
+func TestProbeKeepsMemoryDB(t *testing.T) {
+	silent, _ := logger.New(io.Discard, logger.LevelError, logger.FormatText)
+	logger.SetDefault(silent)
+	db, err := NewMemoryDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	type probed struct{ ID int }
+	if err := db.AutoMigrate(&probed{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&probed{ID: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// the deadline of the ping is over before it starts
+	failing, err := NewMonitor(db, HealthConfig{Timeout: 0}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failing.Probe(context.Background()) {
+		t.Fatal("the probe without time succeeded")
+	}
+	if health := failing.Health(); health.Healthy || health.ConsecutiveFailures != 1 {
+		t.Errorf("health %+v after a failed probe", health)
+	}
+
+	monitor, err := NewMonitor(db, HealthConfig{Timeout: time.Second}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !monitor.Probe(context.Background()) {
+		t.Fatalf("the probe failed: %s", monitor.Health().Error)
+	}
+	var count int64
+	if err := db.Model(&probed{}).Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("%d rows, error %v, the in-memory database is lost", count, err)
+	}
+}
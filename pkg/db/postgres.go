@@ -2,22 +2,51 @@ package db
 
 import (
 	"fmt"
-	"os"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func NewPsqlDB() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=disable password=%s",
-		os.Getenv("BOOK_APP_HOST"),
-		os.Getenv("BOOK_APP_PORT"),
-		os.Getenv("BOOK_APP_USERNAME"),
-		os.Getenv("BOOK_APP_NAME"),
-		os.Getenv("BOOK_APP_PASSWORD"),
+// Config: connection settings of the database
+// Path is only used by the sqlite driver, the others are only used by the postgres driver
+type Config struct {
+	Driver           string        `yaml:"driver"`
+	Path             string        `yaml:"path"`
+	Host             string        `yaml:"host"`
+	Port             string        `yaml:"port"`
+	User             string        `yaml:"user"`
+	Name             string        `yaml:"name"`
+	Password         string        `yaml:"password"`
+	SSLMode          string        `yaml:"sslmode"`
+	StatementTimeout time.Duration `yaml:"statementTimeout"`
+	Pool             PoolConfig    `yaml:"pool"`
+	Health           HealthConfig  `yaml:"health"`
+}
+
+// PoolConfig: sizing and lifetime of the connections in the pool, zero keeps the default of database/sql
+type PoolConfig struct {
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime"`
+}
+
+func NewPsqlDB(c Config) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=%s password=%s",
+		c.Host,
+		c.Port,
+		c.User,
+		c.Name,
+		c.SSLMode,
+		c.Password,
 	)
+	// statement_timeout is sent to the server as a runtime parameter of every connection
+	if c.StatementTimeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", c.StatementTimeout.Milliseconds())
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), newGormConfig())
 	if err != nil {
 		return nil, fmt.Errorf("cannot open database: %v", err)
 	}
@@ -25,6 +54,12 @@ func NewPsqlDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	sqlDB.SetMaxOpenConns(c.Pool.MaxOpenConns)
+	if c.Pool.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(c.Pool.MaxIdleConns)
+	}
+	sqlDB.SetConnMaxLifetime(c.Pool.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(c.Pool.ConnMaxIdleTime)
 
 	if err := sqlDB.Ping(); err != nil {
 		return nil, err
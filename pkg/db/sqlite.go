@@ -0,0 +1,39 @@
+package db
+
+import (
+	"fmt"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// NewSqliteDB: opens the sqlite database in the given file, the file is created if it does not exist
+func NewSqliteDB(path string) (*gorm.DB, error) {
+	return openSqlite(fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path))
+}
+
+// NewMemoryDB: opens an in-memory sqlite database that lives as long as the app
+func NewMemoryDB() (*gorm.DB, error) {
+	return openSqlite("file:bookApp?mode=memory&cache=shared&_foreign_keys=on")
+}
+
+func openSqlite(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(dsn), newGormConfig())
+	if err != nil {
+		return nil, fmt.Errorf("cannot open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows a single writer, a single connection also keeps the in-memory database alive
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
+
+	if err := sqlDB.Ping(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+	ONIX   Format = "onix"
+)
+
+// Writer: writes the rows of a table one by one to the underlying stream
+type Writer interface {
+	Write(values []interface{}) error
+	Close() error
+}
+
+// ParseFormat: parses the format given by the user, csv is used if no format is given
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "csv":
+		return CSV, nil
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "xlsx":
+		return XLSX, nil
+	case "onix":
+		return ONIX, nil
+	}
+	return "", fmt.Errorf("export format %s is not supported", s)
+}
+
+// ContentType: returns the media type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ONIX:
+		return "application/xml; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension: returns the file extension of the format
+func (f Format) Extension() string {
+	switch f {
+	case NDJSON:
+		return "jsonl"
+	case ONIX:
+		return "xml"
+	}
+	return string(f)
+}
+
+// NewWriter: creates a writer of the given format with the given columns
+// ONIX is not a table format, it is written by the onix encoder
+func NewWriter(f Format, w io.Writer, columns []string) (Writer, error) {
+	switch f {
+	case CSV:
+		return newCSVWriter(w, columns)
+	case NDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w), columns: columns}, nil
+	case XLSX:
+		return newXLSXWriter(w, columns)
+	}
+	return nil, fmt.Errorf("export format %s is not supported", f)
+}
+
+// csvWriter: writes the header followed by a line for every row
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (c *csvWriter) Write(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatValue(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ndjsonWriter: writes every row as a json object keyed by the columns on its own line
+type ndjsonWriter struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func (n *ndjsonWriter) Write(values []interface{}) error {
+	object := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		if i < len(n.columns) {
+			object[n.columns[i]] = v
+		}
+	}
+	return n.enc.Encode(object)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// formatValue: converts the value of a cell to its text representation
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float32:
+		return strconv.FormatFloat(float64(t), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
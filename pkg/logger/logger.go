@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level: severity of a log entry, entries below the level of the logger are dropped
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel: parses one of debug, info, warn or error
+func ParseLevel(s string) (Level, error) {
+	for level, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return level, nil
+		}
+	}
+	if strings.EqualFold(s, "warning") {
+		return LevelWarn, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q, must be one of debug, info, warn, error", s)
+}
+
+// Output formats of the entries
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Logger: writes leveled entries with key value fields, a logger is safe for concurrent use
+type Logger struct {
+	out    *output
+	level  Level
+	format string
+	fields []interface{}
+}
+
+// output: the writer shared by a logger and the loggers derived from it
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// New: creates a logger writing the entries at or above the level to w in the given format (json or text)
+func New(w io.Writer, level Level, format string) (*Logger, error) {
+	if format != FormatJSON && format != FormatText {
+		return nil, fmt.Errorf("unknown log format %q, must be json or text", format)
+	}
+	return &Logger{out: &output{w: w}, level: level, format: format}, nil
+}
+
+// With: returns a logger that adds the given key value pairs to every entry
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keysAndValues))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keysAndValues...)
+	return &Logger{out: l.out, level: l.level, format: l.format, fields: fields}
+}
+
+// Enabled: reports whether entries of the level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log(LevelDebug, msg, keysAndValues)
+}
+
+func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
+	l.log(LevelInfo, msg, keysAndValues)
+}
+
+func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log(LevelWarn, msg, keysAndValues)
+}
+
+func (l *Logger) Error(msg string, keysAndValues ...interface{}) {
+	l.log(LevelError, msg, keysAndValues)
+}
+
+// log: writes a single line entry, later fields override the earlier ones with the same key
+func (l *Logger) log(level Level, msg string, keysAndValues []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	keys, values := collect(append(append([]interface{}{}, l.fields...), keysAndValues...))
+
+	var line []byte
+	if l.format == FormatJSON {
+		line = encodeJSON(time.Now(), level, msg, keys, values)
+	} else {
+		line = encodeText(time.Now(), level, msg, keys, values)
+	}
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.w.Write(line)
+}
+
+// collect: pairs the keys with their values in order of appearance, a key without a value gets "!MISSING"
+func collect(keysAndValues []interface{}) ([]string, map[string]interface{}) {
+	keys := []string{}
+	values := map[string]interface{}{}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		var value interface{} = "!MISSING"
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	return keys, values
+}
+
+func encodeJSON(t time.Time, level Level, msg string, keys []string, values map[string]interface{}) []byte {
+	var b strings.Builder
+	b.WriteString(`{"time":`)
+	writeJSON(&b, t.UTC().Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, level.String())
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, msg)
+	for _, key := range keys {
+		if key == "time" || key == "level" || key == "msg" {
+			continue
+		}
+		b.WriteByte(',')
+		writeJSON(&b, key)
+		b.WriteByte(':')
+		writeJSON(&b, values[key])
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func writeJSON(b *strings.Builder, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	b.Write(data)
+}
+
+func encodeText(t time.Time, level Level, msg string, keys []string, values map[string]interface{}) []byte {
+	var b strings.Builder
+	b.WriteString(t.Format("2006/01/02 15:04:05"))
+	b.WriteByte(' ')
+	b.WriteString(strings.ToUpper(level.String()))
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for _, key := range keys {
+		value := fmt.Sprint(values[key])
+		if strings.ContainsAny(value, " \t\n\"=") || value == "" {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = &Logger{out: &output{w: os.Stderr}, level: LevelInfo, format: FormatText}
+)
+
+// Default: returns the logger used when there is no logger in a context
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// SetDefault: replaces the default logger, it is called once the configuration is loaded
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+type contextKey struct{}
+
+// NewContext: returns a copy of the context carrying the logger
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext: returns the logger of the context (e.g. with the fields of a request) or the default logger
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return Default()
+}
+
+// Debug, Info, Warn and Error: write to the default logger
+func Debug(msg string, keysAndValues ...interface{}) { Default().Debug(msg, keysAndValues...) }
+func Info(msg string, keysAndValues ...interface{})  { Default().Info(msg, keysAndValues...) }
+func Warn(msg string, keysAndValues ...interface{})  { Default().Warn(msg, keysAndValues...) }
+func Error(msg string, keysAndValues ...interface{}) { Default().Error(msg, keysAndValues...) }
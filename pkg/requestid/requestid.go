@@ -0,0 +1,46 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Header: header carrying the id that correlates the logs of a request
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+// NewContext: returns a copy of the context carrying the request id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext: returns the request id of the context, empty outside of a request
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New: generates a random request id
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// Valid: accepts ids of at most 128 printable ascii characters without spaces, other incoming ids are replaced
+func Valid(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
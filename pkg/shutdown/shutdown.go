@@ -0,0 +1,83 @@
+package shutdown
+
+import (
+	"bookApp/pkg/logger"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Coordinator: runs the background workers of the app and waits for them to finish on shutdown
+type Coordinator struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]int
+}
+
+func NewCoordinator() *Coordinator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Coordinator{ctx: ctx, cancel: cancel, running: map[string]int{}}
+}
+
+// Context: returns the context that is cancelled when the shutdown starts
+func (c *Coordinator) Context() context.Context {
+	return c.ctx
+}
+
+// Go: runs the worker in the background, the worker should return soon after its context is cancelled
+func (c *Coordinator) Go(name string, worker func(ctx context.Context)) {
+	c.wg.Add(1)
+	c.mu.Lock()
+	c.running[name]++
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running[name]--
+			if c.running[name] == 0 {
+				delete(c.running, name)
+			}
+			c.mu.Unlock()
+			c.wg.Done()
+		}()
+		worker(c.ctx)
+	}()
+}
+
+// Shutdown: cancels the context of the workers and waits for them to finish until the given context is done
+// the workers that are still running at the deadline are reported in the error
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	c.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.FromContext(ctx).Info("background workers are finished")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background workers are still running (%s): %v", strings.Join(c.Running(), ", "), ctx.Err())
+	}
+}
+
+// Running: returns the names of the workers that are still running
+func (c *Coordinator) Running() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.running))
+	for name := range c.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
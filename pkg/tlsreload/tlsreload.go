@@ -0,0 +1,104 @@
+package tlsreload
+
+import (
+	"bookApp/pkg/logger"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval: how often the files are checked for changes, so a renewed certificate is served without a restart
+var checkInterval = 10 * time.Second
+
+// Reloader: serves the certificate of a pair of pem files and loads it again when one of the files is changed
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// New: loads the certificate of the files, an error is returned if they cannot be loaded
+func New(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// filesModTime: the modification time of the file changed last
+func (r *Reloader) filesModTime() (time.Time, error) {
+	latest := time.Time{}
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *Reloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("cannot load the tls certificate %s: %v", r.certFile, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return nil
+}
+
+// reloadIfChanged: loads the files again if they are modified since they were loaded, at most once per check interval
+func (r *Reloader) reloadIfChanged() {
+	r.mu.RLock()
+	due := time.Since(r.checkedAt) >= checkInterval
+	loaded := r.modTime
+	r.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	modTime, err := r.filesModTime()
+	if err == nil && modTime.Equal(loaded) {
+		r.mu.Lock()
+		r.checkedAt = time.Now()
+		r.mu.Unlock()
+		return
+	}
+	// the files may be replaced one after the other, a pair that does not match keeps the certificate loaded before
+	if err == nil {
+		err = r.load(modTime)
+	}
+	if err != nil {
+		logger.Warn("tls certificate is not reloaded", "cert_file", r.certFile, "error", err)
+		r.mu.Lock()
+		r.checkedAt = time.Now()
+		r.mu.Unlock()
+		return
+	}
+	logger.Info("tls certificate is reloaded", "cert_file", r.certFile)
+}
+
+// GetCertificate: returns the current certificate, it is used as the GetCertificate function of a tls.Config
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.reloadIfChanged()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}